@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// glyphs of the badges twitch hands out most - [badges.glyphs.<name>] replaces them
+// badges without a glyph are shown by the first letters of their name
+var builtinBadges = map[string]BadgeGlyph{
+	"broadcaster":        {Glyph: "BC", Color: "red"},
+	"moderator":          {Glyph: "MOD", Color: "green"},
+	"vip":                {Glyph: "VIP", Color: "pink"},
+	"staff":              {Glyph: "STAFF", Color: "mauve"},
+	"admin":              {Glyph: "ADMIN", Color: "mauve"},
+	"global_mod":         {Glyph: "GMOD", Color: "mauve"},
+	"partner":            {Glyph: "✓", Color: "mauve"},
+	"artist-badge":       {Glyph: "ART", Color: "teal"},
+	"subscriber":         {Glyph: "S{months}", Color: "lavender"},
+	"founder":            {Glyph: "F{months}", Color: "peach"},
+	"sub-gifter":         {Glyph: "G{version}", Color: "flamingo"},
+	"bits":               {Glyph: "b{version}", Color: "yellow"},
+	"predictions":        {Glyph: "P", Color: "blue"},
+	"predictions/pink-1": {Glyph: "P", Color: "pink"},
+	"predictions/pink-2": {Glyph: "P", Color: "pink"},
+	"turbo":              {Glyph: "T", Color: "sapphire"},
+	"premium":            {Glyph: "PR", Color: "sapphire"},
+	"no_audio":           {Glyph: "NA", Color: "subtext1"},
+	"no_video":           {Glyph: "NV", Color: "subtext1"},
+}
+
+// text and hex colour of a badge - own glyphs first, name/version before name
+// hidden is true when the badge should not be shown
+func (c Config) BadgeGlyph(name, version string, months int) (text, color string, hidden bool) {
+	glyph := BadgeGlyph{Glyph: fallbackGlyph(name), Color: "subtext1"}
+	for _, key := range []string{name, name + "/" + version} {
+		if builtin, ok := builtinBadges[key]; ok {
+			glyph = builtin
+		}
+	}
+	for _, key := range []string{name, name + "/" + version} {
+		if own, ok := c.Badges.Glyphs[key]; ok {
+			glyph = mergeGlyph(glyph, own)
+		}
+	}
+	if glyph.Hide {
+		return "", "", true
+	}
+
+	tenure := ""
+	if months > 0 {
+		tenure = strconv.Itoa(months)
+	}
+	text = strings.NewReplacer("{months}", tenure, "{version}", version).Replace(glyph.Glyph)
+	return text, c.Theme.colorByName(glyph.Color), false
+}
+
+// the own glyph only replaces what it sets
+func mergeGlyph(glyph, own BadgeGlyph) BadgeGlyph {
+	if own.Glyph != "" {
+		glyph.Glyph = own.Glyph
+	}
+	if own.Color != "" {
+		glyph.Color = own.Color
+	}
+	glyph.Hide = own.Hide
+	return glyph
+}
+
+// moments is MOM, hype-train HYP
+func fallbackGlyph(name string) string {
+	letters := strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(name))
+	if len(letters) > 3 {
+		letters = letters[:3]
+	}
+	return letters
+}
+
+// a hex colour as it is, a theme colour like mauve by its name
+func (t Theme) colorByName(color string) string {
+	if hexColor.MatchString(color) {
+		return color
+	}
+	if field := themeField(&t, strings.ToLower(color)); field.IsValid() && color != "preset" {
+		return field.String()
+	}
+	return t.Text
+}
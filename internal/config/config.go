@@ -2,51 +2,74 @@ package config
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"twitch-tui/internal/atomicfile"
+	"twitch-tui/internal/secrets"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 const defaultRefreshAPI = "https://id.twitch.tv/oauth2/token"
+const defaultEventSubURL = "wss://eventsub.wss.twitch.tv/ws"
+const defaultSubscriptionURL = "https://api.twitch.tv/helix/eventsub/subscriptions"
+const defaultHelixURL = "https://api.twitch.tv/helix"
 const configFileName = "config.toml"
 const appDir = "twitch-tui"
 
 type Twitch struct {
 	Channel    string `toml:"channel"`
 	User       string `toml:"user"`
-	Oauth      string `toml:"oauth"`
-	Refresh    string `toml:"refresh"`
+	Oauth      string `toml:"oauth,omitempty"`   // kept in the secrets store - only here when there is none
+	Refresh    string `toml:"refresh,omitempty"` // same as oauth
 	RefreshApi string `toml:"refresh_api"`
 	UserID     string `toml:"user_id"`
 	ChannelID  string `toml:"channel_id"`
 	ClientID   string `toml:"client_id"`
 }
 
-// stripped down Catppuccin Theme
+// stripped down Catppuccin Theme - empty colours are only left in profile themes and when a preset fills them
 type Theme struct {
-	Base      string `toml:"base"`
-	Subtext1  string `toml:"subtext1"`
-	Text      string `toml:"text"`
-	Lavender  string `toml:"lavender"`
-	Blue      string `toml:"blue"`
-	Sapphire  string `toml:"sapphire"`
-	Sky       string `toml:"sky"`
-	Teal      string `toml:"teal"`
-	Green     string `toml:"green"`
-	Yellow    string `toml:"yellow"`
-	Peach     string `toml:"peach"`
-	Maroon    string `toml:"maroon"`
-	Red       string `toml:"red"`
-	Mauve     string `toml:"mauve"`
-	Pink      string `toml:"pink"`
-	Flamingo  string `toml:"flamingo"`
-	Rosewater string `toml:"rosewater"`
+	Preset    string `toml:"preset,omitempty"` // built-in or own theme the colours come from - the ones set here replace its colours
+	Base      string `toml:"base,omitempty"`
+	Subtext1  string `toml:"subtext1,omitempty"`
+	Text      string `toml:"text,omitempty"`
+	Lavender  string `toml:"lavender,omitempty"`
+	Blue      string `toml:"blue,omitempty"`
+	Sapphire  string `toml:"sapphire,omitempty"`
+	Sky       string `toml:"sky,omitempty"`
+	Teal      string `toml:"teal,omitempty"`
+	Green     string `toml:"green,omitempty"`
+	Yellow    string `toml:"yellow,omitempty"`
+	Peach     string `toml:"peach,omitempty"`
+	Maroon    string `toml:"maroon,omitempty"`
+	Red       string `toml:"red,omitempty"`
+	Mauve     string `toml:"mauve,omitempty"`
+	Pink      string `toml:"pink,omitempty"`
+	Flamingo  string `toml:"flamingo,omitempty"`
+	Rosewater string `toml:"rosewater,omitempty"`
 }
 
 type Style struct {
-	DateFormat string `toml:"date_format"`
+	DateFormat   string  `toml:"date_format"`
+	MinContrast  float64 `toml:"min_contrast"`  // wcag ratio user names need against the background - 0 shows the colours as they are
+	PaletteNames bool    `toml:"palette_names"` // user colours become the closest colour of the theme
+}
+
+// the chat badges in front of the names - see badges.go for the built-in glyphs
+type Badges struct {
+	Enable bool                  `toml:"enable"`
+	Max    int                   `toml:"max"`              // badges shown per message, the rest are counted like +2 - 0 shows all
+	Glyphs map[string]BadgeGlyph `toml:"glyphs,omitempty"` // by badge name or name/version - replaces the built-in glyph
+}
+
+type BadgeGlyph struct {
+	Glyph string `toml:"glyph,omitempty"` // {months} is the tenure of subscribers and founders, {version} the badge version
+	Color string `toml:"color,omitempty"` // hex or a theme colour like mauve
+	Hide  bool   `toml:"hide,omitempty"`
 }
 
 type BitsApi struct {
@@ -83,41 +106,143 @@ type FfzEmotes struct {
 	Color  string `toml:"color"`
 }
 
+type Chat struct {
+	Scrollback  int `toml:"scrollback"`
+	HistorySize int `toml:"history_size"`
+}
+
+// a named chain of commands / chat messages - see Config.Aliases for the template syntax
+type Macro struct {
+	Description string   `toml:"description"`
+	Steps       []string `toml:"steps"`
+}
+
+// external executable speaking json-rpc over stdin / stdout
+type Plugin struct {
+	Name      string   `toml:"name"`
+	Enable    bool     `toml:"enable"`
+	Command   string   `toml:"command"`
+	Args      []string `toml:"args"`
+	TimeoutMs int      `toml:"timeout_ms"` // max time a plugin gets to answer a request
+}
+
+// follows, redemptions, polls, predictions, hype trains and automod holds over a websocket
+type EventSub struct {
+	Enable          bool   `toml:"enable"`
+	URL             string `toml:"url"`              // websocket - point it to a mock server for testing
+	SubscriptionURL string `toml:"subscription_url"` // helix endpoint the subscriptions are created at
+}
+
+// twitch api all helix requests go to - point it to a mock server for testing
+type Helix struct {
+	BaseURL string `toml:"base_url"`
+}
+
+// live status of the channel in the header
+type Stream struct {
+	PollInterval int `toml:"poll_interval"` // seconds
+}
+
+// where the tokens are stored - see the secrets package for the backends
+type Secrets struct {
+	Backend string `toml:"backend"` // auto, keyring or file
+}
+
 type Log struct {
 	Enable bool   `toml:"enable"`
 	Path   string `toml:"path"`
 }
 
 type Config struct {
-	Twitch Twitch `toml:"twitch"`
-	Theme  Theme  `toml:"theme"`
-	Style  Style  `toml:"style"`
-	Api    Api    `toml:"api"`
-	Emotes Emotes `toml:"emotes"`
-	Log    Log    `toml:"log"`
+	Version  int      `toml:"version"` // schema of the file - older ones are migrated on load
+	Twitch   Twitch   `toml:"twitch"`
+	Theme    Theme    `toml:"theme"`
+	Style    Style    `toml:"style"`
+	Badges   Badges   `toml:"badges"`
+	Api      Api      `toml:"api"`
+	Emotes   Emotes   `toml:"emotes"`
+	Chat     Chat     `toml:"chat"`
+	Helix    Helix    `toml:"helix"`
+	EventSub EventSub `toml:"eventsub"`
+	Stream   Stream   `toml:"stream"`
+	Secrets  Secrets  `toml:"secrets"`
+	Log      Log      `toml:"log"`
+
+	// user commands - steps are chained with ; and can use {1} {2} {*} {name} {name:default}
+	Aliases map[string]string `toml:"aliases"`
+	Macros  map[string]Macro  `toml:"macros"`
+
+	Plugins []Plugin `toml:"plugins"`
+
+	Profiles map[string]Profile `toml:"profiles"`
+
+	profile   string // active profile - its values are in Twitch and Theme, see UseProfile
+	base      Twitch // [twitch] and [theme] as they are in the file while a profile is active
+	baseTheme Theme
 }
 
-func Load() Config {
+// read config.toml - a missing file gives the defaults, a broken one an error with the lines to fix
+// files of older versions are backed up and rewritten in the current one
+func Load() (Config, error) {
 	cfg := defaultConfig()
 	configPath, err := getConfigPath()
 	if err != nil {
-		return cfg
+		return cfg, err
 	}
-	if err := loadConfigFile(configPath, &cfg); err != nil {
-		return cfg
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
 	}
 
-	return cfg
+	cfg, version, err := parseConfig(data)
+	if err != nil {
+		return cfg, err
+	}
+
+	rewrite := false
+	if version < currentVersion {
+		suffix := fmt.Sprintf(".v%d.bak", version)
+		if err := backupFile(configPath, suffix, nil); err != nil {
+			return cfg, err
+		}
+		notice("Updated %s from version %d to %d - the old file is kept as %s", configFileName, version, currentVersion, configFileName+suffix)
+		rewrite = true
+	}
+
+	// tokens from older versions are in plain text - move them into the store once
+	moveSecrets := hasPlaintextSecrets(cfg) && openSecrets(cfg.Secrets.Backend) != nil
+	if rewrite || moveSecrets {
+		if _, err := writeConfigFile(configPath, cfg, nil); err != nil {
+			notice("Failed to update %s: %v", configFileName, err)
+		} else if moveSecrets {
+			notice("Moved the tokens from %s into the %s", configFileName, secretsStore.Name())
+		}
+	}
+	if err := loadSecrets(&cfg); err != nil {
+		notice("%v", err)
+	}
+
+	return cfg, nil
 }
 
 func defaultConfig() Config {
 	return Config{
-		Twitch: defaultTwitch(),
-		Theme:  defaultTheme(),
-		Style:  defaultStyle(),
-		Api:    defaultApi(),
-		Emotes: defaultEmotes(),
-		Log:    defaultLog(),
+		Version:  currentVersion,
+		Twitch:   defaultTwitch(),
+		Theme:    defaultTheme(),
+		Style:    defaultStyle(),
+		Badges:   defaultBadges(),
+		Api:      defaultApi(),
+		Emotes:   defaultEmotes(),
+		Chat:     defaultChat(),
+		Helix:    defaultHelix(),
+		EventSub: defaultEventSub(),
+		Stream:   defaultStream(),
+		Secrets:  defaultSecrets(),
+		Log:      defaultLog(),
 	}
 }
 
@@ -159,7 +284,16 @@ func defaultTheme() Theme {
 
 func defaultStyle() Style {
 	return Style{
-		DateFormat: "15:04:05",
+		DateFormat:   "15:04:05",
+		MinContrast:  4.5, // wcag AA for normal text
+		PaletteNames: false,
+	}
+}
+
+func defaultBadges() Badges {
+	return Badges{
+		Enable: true,
+		Max:    3,
 	}
 }
 
@@ -195,226 +329,166 @@ func defaultEmotes() Emotes {
 	}
 }
 
-func defaultLog() Log {
-	return Log{
-		Enable: false,
-		Path:   "",
+func defaultChat() Chat {
+	return Chat{
+		Scrollback:  5000,
+		HistorySize: 500,
 	}
 }
 
-// Upadting the token and refresh token in the config file - on token refresh
-func UpdateTokens(newOauth, newRefresh string) error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
-	}
-
-	cfg, err := readConfigFile(configPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config file: %v", err)
-		}
-		cfg = defaultConfig()
+func defaultHelix() Helix {
+	return Helix{
+		BaseURL: defaultHelixURL,
 	}
-
-	cfg.Twitch.Oauth = newOauth
-	cfg.Twitch.Refresh = newRefresh
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
-	}
-
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
-	}
-
-	return nil
 }
 
-// Update oauth and user on login
-func UpdateLogin(user, oauth string) error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
-	}
-
-	cfg, err := readConfigFile(configPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config file: %v", err)
-		}
-		cfg = defaultConfig()
+func defaultEventSub() EventSub {
+	return EventSub{
+		Enable:          true,
+		URL:             defaultEventSubURL,
+		SubscriptionURL: defaultSubscriptionURL,
 	}
+}
 
-	cfg.Twitch.User = user
-	cfg.Twitch.Oauth = oauth
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
+func defaultStream() Stream {
+	return Stream{
+		PollInterval: 60,
 	}
+}
 
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+func defaultSecrets() Secrets {
+	return Secrets{
+		Backend: secrets.BackendAuto,
 	}
-
-	return nil
 }
 
-// Update the fetched user id
-func UpdateUserID(userID string) error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
+func defaultLog() Log {
+	return Log{
+		Enable: false,
+		Path:   "",
 	}
+}
 
-	cfg, err := readConfigFile(configPath)
+// gets / creates the app directory - .config/twitch-tui | %appdata%/Roaming/twitch-tui
+func Dir() (string, error) {
+	configDir, err := os.UserConfigDir()
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config file: %v", err)
-		}
-		cfg = defaultConfig()
+		return "", fmt.Errorf("failed to get config directory: %v", err)
 	}
 
-	cfg.Twitch.UserID = userID
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
-	}
+	appConfigDir := filepath.Join(configDir, appDir)
 
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	// Create directory if it doesn't exist
+	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %v", err)
 	}
 
-	return nil
+	return appConfigDir, nil
 }
 
-// Update the fetched client id
-func UpdateClientID(clientID string) error {
-	configPath, err := getConfigPath()
+// gets the config file path inside the app directory
+func getConfigPath() (string, error) {
+	appConfigDir, err := Dir()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	cfg, err := readConfigFile(configPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config file: %v", err)
-		}
-		cfg = defaultConfig()
-	}
+	return filepath.Join(appConfigDir, configFileName), nil
+}
 
-	cfg.Twitch.ClientID = clientID
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
-	}
+// decode the defaults overwritten by the file - older versions are migrated first
+// returns the version the file had, syntax and validation errors point to the line
+func parseConfig(data []byte) (Config, int, error) {
+	cfg := defaultConfig()
 
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	var raw map[string]any
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return cfg, 0, decodeError(err)
 	}
-
-	return nil
-}
-
-// Update the fetched channel id
-func UpdateChannelID(channelID string) error {
-	configPath, err := getConfigPath()
+	version, err := migrate(raw)
 	if err != nil {
-		return err
+		return cfg, version, err
 	}
 
-	cfg, err := readConfigFile(configPath)
+	unknown, err := decodeStrict(data, &cfg) // type errors with the lines of the file
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config file: %v", err)
+		return cfg, version, decodeError(err)
+	}
+	if version != currentVersion { // keys an older version had are checked once they are migrated
+		migrated, err := toml.Marshal(raw)
+		if err != nil {
+			return cfg, version, err
 		}
 		cfg = defaultConfig()
+		if unknown, err = decodeStrict(migrated, &cfg); err != nil {
+			return cfg, version, fmt.Errorf("%s after migrating from version %d: %v", configFileName, version, err)
+		}
 	}
 
-	cfg.Twitch.ChannelID = channelID
 	if cfg.Twitch.RefreshApi == "" {
 		cfg.Twitch.RefreshApi = defaultRefreshAPI
 	}
-
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	errs := append(unknown, resolvePresets(&cfg, raw)...)
+	if errs = append(errs, cfg.validate()...); len(errs) > 0 {
+		return cfg, version, errs.withLines(data)
 	}
-
-	return nil
+	return cfg, version, nil
 }
 
-// completely update / overwrite config
-func UpdateConfig(cfg Config) error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
+// toml.Unmarshal that reports keys the config does not have - a typo would otherwise give the default
+// and be dropped with the next write of the file
+func decodeStrict(data []byte, cfg *Config) (ValidationError, error) {
+	err := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(cfg)
+	var strictErr *toml.StrictMissingError
+	if !errors.As(err, &strictErr) {
+		return nil, err
 	}
 
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
+	var errs ValidationError
+	for _, missing := range strictErr.Errors {
+		line, _ := missing.Position()
+		errs = append(errs, FieldError{Line: line, Key: strings.Join(missing.Key(), "."), Msg: "is not a setting - check the spelling in the readme"})
 	}
-
-	if err := writeConfigFile(configPath, cfg); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
-	}
-
-	return nil
+	return errs, nil
 }
 
-// gets / creates the config path - .config/twitch-tui | %appdata%/Roaming/twitch-tui
-func getConfigPath() (string, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get config directory: %v", err)
-	}
-
-	appConfigDir := filepath.Join(configDir, appDir)
-
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create config directory: %v", err)
+func decodeError(err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, col := decodeErr.Position()
+		return fmt.Errorf("%s line %d, column %d: %s", configFileName, row, col, decodeErr.Error())
 	}
-
-	return filepath.Join(appConfigDir, configFileName), nil
+	return fmt.Errorf("%s: %v", configFileName, err)
 }
 
-// opens file - decodes toml - updates cfg pointer
-func loadConfigFile(path string, cfg *Config) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
+// write config to disk - the tokens go into the secrets store
+// the file is copied to config.toml.bak first unless it still is what we wrote last
+// returns what was written - the watcher skips our own writes with it
+func writeConfigFile(path string, cfg Config, written []byte) ([]byte, error) {
+	if err := backupFile(path, ".bak", written); err != nil {
+		return nil, err
 	}
-	defer file.Close()
 
-	decoder := toml.NewDecoder(file)
-	if err := decoder.Decode(cfg); err != nil {
-		return fmt.Errorf("failed to parse config file: %v", err)
+	cfg = cfg.fileView()
+	cfg.Theme = cfg.Theme.stored()
+	cfg.Profiles = cloneProfiles(cfg.Profiles)
+	for name, profile := range cfg.Profiles {
+		profile.Theme = profile.Theme.stored()
+		cfg.Profiles[name] = profile
 	}
-
-	if cfg.Twitch.RefreshApi == "" {
-		cfg.Twitch.RefreshApi = defaultRefreshAPI
+	if err := saveSecrets(&cfg); err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// loads defautl config then overwrites it with the conf in the file
-func readConfigFile(path string) (Config, error) {
-	cfg := defaultConfig()
-	if err := loadConfigFile(path, &cfg); err != nil {
-		return cfg, err
-	}
-
-	return cfg, nil
-}
-
-// write config to disk
-func writeConfigFile(path string, cfg Config) error {
 	var buf bytes.Buffer
 	encoder := toml.NewEncoder(&buf)
 	if err := encoder.Encode(cfg); err != nil {
-		return fmt.Errorf("failed to encode config file: %v", err)
+		return nil, fmt.Errorf("failed to encode config file: %v", err)
 	}
 
-	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
-		return err
+	mode := os.FileMode(0644)
+	if hasPlaintextSecrets(cfg) { // no secrets store - at least keep the tokens private
+		mode = 0600
 	}
-
-	return nil
+	return buf.Bytes(), atomicfile.WriteFile(path, buf.Bytes(), mode)
 }
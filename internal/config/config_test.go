@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"twitch-tui/internal/secrets"
+)
+
+func TestParseConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		wantKeys []string
+		wantLine int // of the first error
+	}{
+		{"typo in a section", "[style]\ndate_fromat = \"hh:mm\"\n", []string{"style.date_fromat"}, 2},
+		{"unknown section", "[chat]\nscrollback = 500\n\n[sytle]\ndate_format = \"15:04\"\n", []string{"sytle"}, 4},
+		{"top level", "verison = 1\n", []string{"verison"}, 1},
+		{"dotted key", "chat.scrollbak = 500\n", []string{"chat.scrollbak"}, 1},
+		{"in a profile", "[profiles.alt]\nuser = \"alt\"\nchanels = [\"foo\"]\n", []string{"profiles.alt.chanels"}, 3},
+		{"in a glyph", "[badges.glyphs.vip]\nglpyh = \"V\"\n", []string{"badges.glyphs.vip.glpyh"}, 2},
+		{"in a plugin", "[[plugins]]\nname = \"x\"\ncomand = \"x\"\n", []string{"plugins.comand"}, 3},
+		{"several", "[chat]\nscrollbak = 1\nhistory = 2\n", []string{"chat.scrollbak", "chat.history"}, 2},
+		{"map keys are free", "[aliases]\nanything = \":say hi\"\n\n[badges.glyphs.\"predictions/blue-1\"]\nglyph = \"B\"\n", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseConfig([]byte(tt.file))
+			if tt.wantKeys == nil {
+				if err != nil {
+					t.Fatalf("parseConfig error = %v, want nil", err)
+				}
+				return
+			}
+
+			var invalid ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("parseConfig error = %v, want a ValidationError", err)
+			}
+			if len(invalid) != len(tt.wantKeys) {
+				t.Fatalf("errors = %v, want %d", invalid, len(tt.wantKeys))
+			}
+			for i, key := range tt.wantKeys {
+				if invalid[i].Key != key {
+					t.Errorf("error %d is for %q, want %q", i, invalid[i].Key, key)
+				}
+			}
+			if invalid[0].Line != tt.wantLine {
+				t.Errorf("line = %d, want %d", invalid[0].Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestWrittenConfigParses(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(secrets.PassphraseEnv, "test")
+
+	cfg := defaultConfig()
+	cfg.Secrets.Backend = secrets.BackendFile // never the keyring of the machine
+	cfg.Aliases = map[string]string{"hi": ":say hi"}
+	cfg.Profiles = map[string]Profile{"alt": {User: "alt", Channels: []string{"foo"}}}
+	cfg.Plugins = []Plugin{{Name: "p", Command: "p"}}
+	cfg.Badges.Glyphs = map[string]BadgeGlyph{"vip": {Glyph: "V"}}
+
+	path := filepath.Join(t.TempDir(), configFileName)
+	data, err := writeConfigFile(path, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := parseConfig(data); err != nil {
+		t.Errorf("parseConfig of a written file = %v\n%s", err, data)
+	}
+	if _, err := os.Stat(path + ".bak"); err == nil {
+		t.Error("a new file was backed up")
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// keys that :config can not show - the tokens never leave the secrets store
+var hiddenKeys = []string{"twitch.oauth", "twitch.refresh"}
+
+// sections :config set leaves alone - the account is changed by :login, :join and :account
+var readOnlyKeys = []string{"version", "twitch"}
+
+// every key :config can show - dotted toml paths of the plain values, sorted like the file
+// lists, aliases, macros, plugins and profiles are left to the file
+func Keys() []string {
+	var keys []string
+	walkKeys(reflect.ValueOf(Config{}), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// top level tables that have keys
+func Sections() []string {
+	var sections []string
+	for _, key := range Keys() {
+		section, _, found := strings.Cut(key, ".")
+		if found && !slices.Contains(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// the type of a key for completion and :config list - string, bool, int or float64
+func KeyKind(key string) string {
+	var kind string
+	walkKeys(reflect.ValueOf(Config{}), "", func(k string, field reflect.Value) {
+		if k == key {
+			kind = field.Kind().String()
+		}
+	})
+	return kind
+}
+
+// value of a key as it would be written in :config set
+func (c Config) Get(key string) (string, error) {
+	field, err := c.field(key)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(field), nil
+}
+
+// set a key from text - the value has to fit the type and pass validation
+func (c *Config) Set(key, value string) error {
+	if ReadOnly(key) {
+		return fmt.Errorf("%s can not be set here - the account is changed with :login, :join and :account", key)
+	}
+	if key == "theme.preset" { // all colours change with it
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		return c.UseTheme(value)
+	}
+
+	field, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	if err := parseValue(field, key, value); err != nil {
+		return err
+	}
+
+	// theme colours of a profile are saved in the profile - the main theme would not keep them
+	if section, name, _ := strings.Cut(key, "."); section == "theme" && c.profile != "" {
+		profile := c.Profiles[c.profile]
+		themeField(&profile.Theme, name).SetString(field.String())
+		c.Profiles[c.profile] = profile
+	}
+
+	for _, fieldErr := range c.validate() {
+		if fieldErr.Key == key || fieldErr.Key == "profiles."+c.profile+"."+key {
+			return fmt.Errorf("%s %s", key, fieldErr.Msg)
+		}
+	}
+	return nil
+}
+
+// the settable field of a key
+func (c *Config) field(key string) (reflect.Value, error) {
+	var found reflect.Value
+	walkKeys(reflect.ValueOf(c).Elem(), "", func(k string, field reflect.Value) {
+		if k == key {
+			found = field
+		}
+	})
+	if !found.IsValid() {
+		return found, fmt.Errorf("unknown key %q - :config list shows all keys", key)
+	}
+	return found, nil
+}
+
+// call fn for every plain value below v
+func walkKeys(v reflect.Value, prefix string, fn func(key string, field reflect.Value)) {
+	for i := range v.NumField() {
+		structField := v.Type().Field(i)
+		name := tomlName(structField)
+		if !structField.IsExported() || name == "" {
+			continue
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if slices.Contains(hiddenKeys, key) {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			walkKeys(field, key, fn)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+			fn(key, field)
+		}
+	}
+}
+
+// keys :config set refuses
+func ReadOnly(key string) bool {
+	for _, prefix := range readOnlyKeys {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.String:
+		return strconv.Quote(field.String())
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'g', -1, 64)
+	default:
+		return strconv.FormatInt(field.Int(), 10)
+	}
+}
+
+func parseValue(field reflect.Value, key, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		field.SetString(value)
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "true", "on", "yes", "enable":
+			field.SetBool(true)
+		case "false", "off", "no", "disable":
+			field.SetBool(false)
+		default:
+			return fmt.Errorf("%s takes true or false, not %q", key, value)
+		}
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s takes a whole number, not %q", key, value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s takes a number, not %q", key, value)
+		}
+		field.SetFloat(n)
+	default:
+		return errors.New(key + " can not be set")
+	}
+	return nil
+}
+
+// the colour of a theme by its toml name
+func themeField(theme *Theme, name string) reflect.Value {
+	v := reflect.ValueOf(theme).Elem()
+	for i := range v.NumField() {
+		if tomlName(v.Type().Field(i)) == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigSet(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // no own themes of the machine
+
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		want    string // Get afterwards - the store drops the config when Set fails
+		wantErr string // part of the error
+	}{
+		{"bool on", "badges.enable", "off", "false", ""},
+		{"bool yes", "style.palette_names", "YES", "true", ""},
+		{"bool garbage", "badges.enable", "maybe", "", "takes true or false"},
+		{"int", "chat.scrollback", "2000", "2000", ""},
+		{"int garbage", "chat.scrollback", "lots", "", "takes a whole number"},
+		{"int float", "badges.max", "2.5", "", "takes a whole number"},
+		{"float", "style.min_contrast", "7", "7", ""},
+		{"float out of range", "style.min_contrast", "30", "", "contrast ratio from 1 to 21"},
+		{"string", "style.date_format", "15:04", `"15:04"`, ""},
+		{"quoted string", "style.date_format", `"15:04 "`, `"15:04 "`, ""},
+		{"string with spaces", "style.date_format", "Jan 2 15:04", `"Jan 2 15:04"`, ""},
+		{"bad layout", "style.date_format", "hh:mm", "", "go time layout"},
+		{"colour", "theme.base", "#000000", `"#000000"`, ""},
+		{"invalid colour", "theme.base", "black", "", "is not a hex colour"},
+		{"theme preset", "theme.preset", "nord", `"nord"`, ""},
+		{"quoted preset", "theme.preset", `"dracula"`, `"dracula"`, ""},
+		{"unknown preset", "theme.preset", "neon", "", "unknown theme"},
+		{"unknown key", "chat.colour", "1", "", "unknown key"},
+		{"section", "chat", "1", "", "unknown key"},
+		{"read only", "twitch.channel", "foo", "", "can not be set here"},
+		{"hidden", "twitch.oauth", "abc", "", "can not be set here"},
+		{"version", "version", "2", "", "can not be set here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			err := cfg.Set(tt.key, tt.value)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Set(%q, %q) error = %v", tt.key, tt.value, err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Set(%q, %q) error = %v, want one with %q", tt.key, tt.value, err, tt.wantErr)
+			}
+
+			if tt.wantErr != "" {
+				return
+			}
+			if got, _ := cfg.Get(tt.key); got != tt.want {
+				t.Errorf("Get(%q) = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSetPresetColours(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg := defaultConfig()
+	if err := cfg.Set("theme.preset", "catppuccin-latte"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cfg.Get("theme.base"); got != `"#eff1f5"` {
+		t.Errorf("theme.base = %s after the preset, want the latte base", got)
+	}
+}
+
+func TestConfigSetProfileTheme(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Profiles = map[string]Profile{"alt": {User: "alt"}}
+	if err := cfg.UseProfile("alt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.Set("theme.red", "#ff0000"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Profiles["alt"].Theme.Red; got != "#ff0000" {
+		t.Errorf("profile theme red = %q, want the new colour kept in the profile", got)
+	}
+	if got := cfg.fileView().Theme.Red; got != defaultTheme().Red {
+		t.Errorf("main theme red = %q, want it unchanged", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	for _, key := range []string{"version", "chat.scrollback", "theme.base", "emotes.sevenTv.color", "twitch.channel"} {
+		if KeyKind(key) == "" {
+			t.Errorf("%s is missing from Keys", key)
+		}
+	}
+	for _, key := range keys {
+		if key == "twitch.oauth" || key == "twitch.refresh" {
+			t.Errorf("Keys lists the secret %s", key)
+		}
+		if _, err := defaultConfig().Get(key); err != nil {
+			t.Errorf("Get(%q) error = %v", key, err)
+		}
+	}
+	if got, err := defaultConfig().Get("twitch.oauth"); err == nil {
+		t.Errorf("Get(twitch.oauth) = %s, want an error - tokens stay in the secrets store", got)
+	}
+}
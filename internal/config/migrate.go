@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"twitch-tui/internal/atomicfile"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// schema of config.toml - files without a version are 1
+// bump it together with a new entry in migrations
+const currentVersion = 1
+
+// migrations[i] turns a version i+1 file into version i+2 - they work on the raw toml tables
+var migrations = []func(raw map[string]any){}
+
+// bring the tables of an older file up to the current version - returns the version the file had
+func migrate(raw map[string]any) (int, error) {
+	version := 1
+	if value, ok := raw["version"]; ok {
+		v, ok := value.(int64)
+		if !ok || v < 1 {
+			return 0, fmt.Errorf("%s: version %v is not a positive number", configFileName, value)
+		}
+		version = int(v)
+	}
+	if version > currentVersion {
+		return version, fmt.Errorf("%s is version %d but this build only knows up to %d - update twitch-tui or remove the file", configFileName, version, currentVersion)
+	}
+
+	for _, migration := range migrations[version-1:] {
+		migration(raw)
+	}
+	raw["version"] = currentVersion
+	return version, nil
+}
+
+// oauth = "..." and refresh = "..." lines of [twitch] and the profiles - also as dotted keys like twitch.oauth
+var tokenLine = regexp.MustCompile(`(?m)^(\s*(?:[\w"'-]+\s*\.\s*)*["']?(?:oauth|refresh)["']?\s*=\s*).*$`)
+
+// copy the file next to it before we overwrite it - skipped when it still is what we wrote
+// tokens are blanked in the copy, they live in the secrets store or in config.toml itself
+func backupFile(path, suffix string, written []byte) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if written != nil && bytes.Equal(data, written) {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data = tokenLine.ReplaceAll(data, []byte(`${1}""`))
+	if hasPlaintextSecrets(backupView(data)) { // inline tables - better no copy than one with the tokens
+		notice("%s was not backed up - its tokens are in a form that can not be blanked in the copy", configFileName)
+		return nil
+	}
+	if err := atomicfile.WriteFile(path+suffix, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", configFileName, err)
+	}
+	return nil
+}
+
+// what a backup still holds - a file toml can not read counts as one without tokens
+func backupView(data []byte) Config {
+	var cfg Config
+	_ = toml.Unmarshal(data, &cfg)
+	return cfg
+}
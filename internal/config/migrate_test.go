@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    int // version the file had
+		wantErr bool
+	}{
+		{"no version is 1", "[chat]\nscrollback = 500\n", 1, false},
+		{"empty file", "", 1, false},
+		{"current", "version = 1\n", 1, false},
+		{"newer build", "version = 2\n", 2, true},
+		{"zero", "version = 0\n", 0, true},
+		{"negative", "version = -1\n", 0, true},
+		{"string", "version = \"1\"\n", 0, true},
+		{"float", "version = 1.0\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]any
+			if err := toml.Unmarshal([]byte(tt.file), &raw); err != nil {
+				t.Fatal(err)
+			}
+			if raw == nil {
+				raw = map[string]any{}
+			}
+
+			got, err := migrate(raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrate error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("migrate = %d, want %d", got, tt.want)
+			}
+			if err == nil && raw["version"] != currentVersion {
+				t.Errorf("version after migrate = %v, want %d", raw["version"], currentVersion)
+			}
+		})
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string // "" when no backup is written
+	}{
+		{
+			name: "tokens blanked",
+			file: "[twitch]\nchannel = \"foo\"\noauth = \"SECRET\"\nrefresh = 'SECRET' # comment\nrefresh_api = \"https://example.com\"\n",
+			want: "[twitch]\nchannel = \"foo\"\noauth = \"\"\nrefresh = \"\"\nrefresh_api = \"https://example.com\"\n",
+		},
+		{
+			name: "profiles",
+			file: "[profiles.alt]\n  user = \"alt\"\n  oauth=\"SECRET\"\n",
+			want: "[profiles.alt]\n  user = \"alt\"\n  oauth=\"\"\n",
+		},
+		{
+			name: "dotted keys",
+			file: "twitch.oauth = \"SECRET\"\nprofiles.alt.\"refresh\" = \"SECRET\"\n",
+			want: "twitch.oauth = \"\"\nprofiles.alt.\"refresh\" = \"\"\n",
+		},
+		{
+			name: "no tokens",
+			file: "# my config\n[chat]\nscrollback = 500\n",
+			want: "# my config\n[chat]\nscrollback = 500\n",
+		},
+		{
+			name: "inline table is not copied",
+			file: "[profiles]\nalt = { user = \"alt\", oauth = \"SECRET\" }\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := backupFile(path, ".bak", nil); err != nil {
+				t.Fatal(err)
+			}
+			Notices()
+
+			data, err := os.ReadFile(path + ".bak")
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("backup written: %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("backup = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
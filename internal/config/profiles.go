@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// a named identity - e.g. a personal and a bot account
+type Profile struct {
+	User     string   `toml:"user"`
+	ClientID string   `toml:"client_id"`
+	UserID   string   `toml:"user_id"`
+	Oauth    string   `toml:"oauth,omitempty"`   // kept in the secrets store like the one in [twitch]
+	Refresh  string   `toml:"refresh,omitempty"` // same as oauth
+	Channels []string `toml:"channels"`          // the first one is joined, all of them are offered on :join
+	Theme    Theme    `toml:"theme,omitempty"`   // only the colours set here replace the main theme
+}
+
+// names of the profiles in the config - sorted
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the profile in use - empty for [twitch]
+func (c Config) ActiveProfile() string {
+	return c.profile
+}
+
+// channels of the active profile
+func (c Config) ProfileChannels() []string {
+	if c.profile == "" {
+		return nil
+	}
+	return c.Profiles[c.profile].Channels
+}
+
+// put the credentials, channel and theme of the profile over [twitch] and [theme]
+// the file keeps both apart - see fileView
+func (c *Config) UseProfile(name string) error {
+	if _, ok := c.Profiles[name]; name != "" && !ok {
+		available := strings.Join(c.ProfileNames(), ", ")
+		if available == "" {
+			available = "none - add them as [profiles.<name>] to config.toml"
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", name, available)
+	}
+
+	*c = c.fileView() // back to the plain [twitch] values before the next profile goes on top
+	if name == "" {
+		return nil
+	}
+
+	c.profile = name
+	c.base = c.Twitch
+	c.baseTheme = c.Theme
+
+	profile := c.Profiles[name]
+	c.Twitch.User = profile.User
+	c.Twitch.ClientID = profile.ClientID
+	c.Twitch.UserID = profile.UserID
+	c.Twitch.Oauth = profile.Oauth
+	c.Twitch.Refresh = profile.Refresh
+	if len(profile.Channels) > 0 {
+		c.Twitch.Channel = strings.TrimPrefix(profile.Channels[0], "#")
+		c.Twitch.ChannelID = ""
+	}
+	overlayTheme(&c.Theme, profile.Theme)
+	return nil
+}
+
+// the config as it is stored - credentials of the profile go back into it, [twitch] and [theme] get their own values again
+func (c Config) fileView() Config {
+	if c.profile == "" {
+		return c
+	}
+
+	profile := c.Profiles[c.profile]
+	profile.User = c.Twitch.User
+	profile.ClientID = c.Twitch.ClientID
+	profile.UserID = c.Twitch.UserID
+	profile.Oauth = c.Twitch.Oauth
+	profile.Refresh = c.Twitch.Refresh
+
+	profiles := make(map[string]Profile, len(c.Profiles))
+	for name, p := range c.Profiles {
+		profiles[name] = p
+	}
+	profiles[c.profile] = profile
+	c.Profiles = profiles
+
+	twitch := c.base
+	if len(profile.Channels) == 0 { // the channel was ours to change
+		twitch.Channel = c.Twitch.Channel
+		twitch.ChannelID = c.Twitch.ChannelID
+	}
+	c.Twitch = twitch
+	c.Theme = c.baseTheme
+	c.profile = ""
+	return c
+}
+
+// set colours replace the ones of the theme
+func overlayTheme(theme *Theme, overlay Theme) {
+	dst := reflect.ValueOf(theme).Elem()
+	src := reflect.ValueOf(overlay)
+	for i := range src.NumField() {
+		if value := src.Field(i); value.String() != "" {
+			dst.Field(i).Set(value)
+		}
+	}
+}
+
+// secret keys of a profile
+func profileSecret(name, field string) string {
+	return "profile." + name + "." + field
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"twitch-tui/internal/secrets"
+)
+
+// names of the tokens in the secrets store
+const (
+	secretOauth   = "twitch.oauth"
+	secretRefresh = "twitch.refresh"
+)
+
+var (
+	secretsOnce    sync.Once
+	secretsStore   secrets.Store // nil when no store could be opened - tokens then stay in config.toml
+	unreadableOnce sync.Once     // a store we can not decrypt is reported on start, not on every reload
+	notices        []string
+)
+
+// messages for the user from loading the config - cleared on read
+func Notices() []string {
+	n := notices
+	notices = nil
+	return n
+}
+
+func notice(format string, args ...any) {
+	notices = append(notices, fmt.Sprintf(format, args...))
+}
+
+// the store of the backend the first loaded config names
+func openSecrets(backend string) secrets.Store {
+	secretsOnce.Do(func() {
+		dir, err := Dir()
+		if err == nil {
+			secretsStore, err = secrets.Open(backend, dir)
+		}
+		if err != nil {
+			notice("Secrets store unavailable, tokens are kept in config.toml: %v", err)
+		}
+	})
+	return secretsStore
+}
+
+// fill the tokens from the store - tokens still in the file win, they get migrated on the next write
+func loadSecrets(cfg *Config) error {
+	store := openSecrets(cfg.Secrets.Backend)
+	if store == nil {
+		return nil
+	}
+
+	return withSecretFields(cfg, func(fields map[string]*string) error {
+		for key, value := range fields {
+			if *value != "" {
+				continue
+			}
+			secret, err := store.Get(key)
+			if errors.Is(err, secrets.ErrNotFound) {
+				continue
+			}
+			if errors.Is(err, secrets.ErrUnreadable) { // logged out instead of a config that can not be saved
+				unreadableOnce.Do(func() {
+					notice("%v - use :login to sign in again, the old file is then kept as %s.unreadable", err, secrets.FileName)
+				})
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s from the %s: %v", key, store.Name(), err)
+			}
+			*value = secret
+		}
+		return nil
+	})
+}
+
+// move the tokens into the store and blank them for the file
+// without a store they stay - writeConfigFile then keeps the file private
+func saveSecrets(cfg *Config) error {
+	store := openSecrets(cfg.Secrets.Backend)
+	if store == nil {
+		return nil
+	}
+
+	return withSecretFields(cfg, func(fields map[string]*string) error {
+		for key, value := range fields {
+			var err error
+			if *value == "" {
+				err = store.Delete(key)
+			} else {
+				err = store.Set(key, *value)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to store %s in the %s: %v", key, store.Name(), err)
+			}
+			*value = ""
+		}
+		return nil
+	})
+}
+
+// every token of [twitch] and the profiles by its secret key - changes end up in cfg
+func withSecretFields(cfg *Config, fn func(fields map[string]*string) error) error {
+	fields := map[string]*string{
+		secretOauth:   &cfg.Twitch.Oauth,
+		secretRefresh: &cfg.Twitch.Refresh,
+	}
+
+	profiles := make(map[string]*Profile, len(cfg.Profiles)) // map values are not addressable
+	for name, profile := range cfg.Profiles {
+		profiles[name] = &profile
+		fields[profileSecret(name, "oauth")] = &profile.Oauth
+		fields[profileSecret(name, "refresh")] = &profile.Refresh
+	}
+
+	err := fn(fields)
+	if len(profiles) > 0 {
+		cfg.Profiles = make(map[string]Profile, len(profiles))
+		for name, profile := range profiles {
+			cfg.Profiles[name] = *profile
+		}
+	}
+	return err
+}
+
+// true when the file still has tokens in plain text
+func hasPlaintextSecrets(cfg Config) bool {
+	plain := false
+	_ = withSecretFields(&cfg, func(fields map[string]*string) error {
+		for _, value := range fields {
+			plain = plain || *value != ""
+		}
+		return nil
+	})
+	return plain
+}
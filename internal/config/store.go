@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+// the one config of the running app - the service and the tui read it here and change it through Update
+// every change is written to disk right away, subscribers get the new config afterwards
+type Store struct {
+	mu      sync.Mutex
+	cfg     Config
+	subs    []chan Config
+	saved   []byte // file content of our last write or reload - file events with it are our own
+	written []byte // file content of our last write - anything else on disk is backed up before we overwrite it
+}
+
+func NewStore(cfg Config) *Store {
+	return &Store{cfg: cfg}
+}
+
+// copy of the current config
+func (s *Store) Get() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// change the config and save it - an error from change keeps the old config
+// a failed write is returned but the change stays in use until the next save
+func (s *Store) Update(change func(*Config) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.cfg
+	cfg.Profiles = cloneProfiles(cfg.Profiles) // change may edit them - the old map stays with the old config
+	if err := change(&cfg); err != nil {
+		return err
+	}
+	if cfg.Twitch.RefreshApi == "" {
+		cfg.Twitch.RefreshApi = defaultRefreshAPI
+	}
+
+	s.cfg = cfg
+	s.notify()
+
+	path, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := writeConfigFile(path, cfg, s.written)
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	s.saved = data
+	s.written = data
+	return nil
+}
+
+// swap the whole config without saving it - for configs that were just read from disk
+func (s *Store) Replace(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.notify()
+}
+
+// new configs after every change - only the latest one is kept when the reader is slow
+func (s *Store) Subscribe() <-chan Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan Config, 1)
+	s.subs = append(s.subs, ch)
+	return ch
+}
+
+// called with the lock held - nobody else sends on the channels
+func (s *Store) notify() {
+	for _, ch := range s.subs {
+		select {
+		case <-ch: // drop the update the reader did not get to yet
+		default:
+		}
+		ch <- s.cfg
+	}
+}
+
+func cloneProfiles(profiles map[string]Profile) map[string]Profile {
+	if profiles == nil {
+		return nil
+	}
+	clone := make(map[string]Profile, len(profiles))
+	for name, profile := range profiles {
+		clone[name] = profile
+	}
+	return clone
+}
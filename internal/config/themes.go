@@ -0,0 +1,308 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"twitch-tui/internal/atomicfile"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// own themes are <name>.toml in this directory next to config.toml - same keys as [theme]
+const themesDir = "themes"
+
+// built-in palettes - :theme <name> or preset = "<name>" in [theme]
+var presets = map[string]Theme{
+	"catppuccin-latte": {
+		Base: "#eff1f5", Subtext1: "#7c7f93", Text: "#4c4f69", Lavender: "#7287fd", Blue: "#1e66f5", Sapphire: "#209fb5",
+		Sky: "#04a5e5", Teal: "#179299", Green: "#40a02b", Yellow: "#df8e1d", Peach: "#fe640b", Maroon: "#e64553",
+		Red: "#d20f39", Mauve: "#8839ef", Pink: "#ea76cb", Flamingo: "#dd7878", Rosewater: "#dc8a78",
+	},
+	"catppuccin-frappe": defaultTheme(),
+	"catppuccin-macchiato": {
+		Base: "#24273a", Subtext1: "#939ab7", Text: "#cad3f5", Lavender: "#b7bdf8", Blue: "#8aadf4", Sapphire: "#7dc4e4",
+		Sky: "#91d7e3", Teal: "#8bd5ca", Green: "#a6da95", Yellow: "#eed49f", Peach: "#f5a97f", Maroon: "#ee99a0",
+		Red: "#ed8796", Mauve: "#c6a0f6", Pink: "#f5bde6", Flamingo: "#f0c6c6", Rosewater: "#f4dbd6",
+	},
+	"catppuccin-mocha": {
+		Base: "#1e1e2e", Subtext1: "#9399b2", Text: "#cdd6f4", Lavender: "#b4befe", Blue: "#89b4fa", Sapphire: "#74c7ec",
+		Sky: "#89dceb", Teal: "#94e2d5", Green: "#a6e3a1", Yellow: "#f9e2af", Peach: "#fab387", Maroon: "#eba0ac",
+		Red: "#f38ba8", Mauve: "#cba6f7", Pink: "#f5c2e7", Flamingo: "#f2cdcd", Rosewater: "#f5e0dc",
+	},
+	"gruvbox": {
+		Base: "#282828", Subtext1: "#928374", Text: "#ebdbb2", Lavender: "#d5c4a1", Blue: "#83a598", Sapphire: "#458588",
+		Sky: "#8ec07c", Teal: "#689d6a", Green: "#b8bb26", Yellow: "#fabd2f", Peach: "#fe8019", Maroon: "#cc241d",
+		Red: "#fb4934", Mauve: "#b16286", Pink: "#d3869b", Flamingo: "#d65d0e", Rosewater: "#fbf1c7",
+	},
+	"nord": {
+		Base: "#2e3440", Subtext1: "#7b88a1", Text: "#d8dee9", Lavender: "#e5e9f0", Blue: "#81a1c1", Sapphire: "#5e81ac",
+		Sky: "#88c0d0", Teal: "#8fbcbb", Green: "#a3be8c", Yellow: "#ebcb8b", Peach: "#d08770", Maroon: "#bf616a",
+		Red: "#bf616a", Mauve: "#b48ead", Pink: "#b48ead", Flamingo: "#d08770", Rosewater: "#eceff4",
+	},
+	"solarized": {
+		Base: "#002b36", Subtext1: "#586e75", Text: "#839496", Lavender: "#6c71c4", Blue: "#268bd2", Sapphire: "#268bd2",
+		Sky: "#2aa198", Teal: "#2aa198", Green: "#859900", Yellow: "#b58900", Peach: "#cb4b16", Maroon: "#dc322f",
+		Red: "#dc322f", Mauve: "#6c71c4", Pink: "#d33682", Flamingo: "#d33682", Rosewater: "#93a1a1",
+	},
+	"dracula": {
+		Base: "#282a36", Subtext1: "#6272a4", Text: "#f8f8f2", Lavender: "#caa9fa", Blue: "#8be9fd", Sapphire: "#8be9fd",
+		Sky: "#8be9fd", Teal: "#50fa7b", Green: "#50fa7b", Yellow: "#f1fa8c", Peach: "#ffb86c", Maroon: "#ff6e6e",
+		Red: "#ff5555", Mauve: "#bd93f9", Pink: "#ff79c6", Flamingo: "#ff92df", Rosewater: "#f8f8f2",
+	},
+}
+
+// base16 slots of every colour - base00 is the background, base05 the text, base08 - base0F the accents
+var base16Slots = map[string]string{
+	"base": "base00", "subtext1": "base04", "text": "base05", "rosewater": "base06", "lavender": "base07",
+	"red": "base08", "maroon": "base08", "peach": "base09", "yellow": "base0A", "green": "base0B",
+	"teal": "base0C", "sky": "base0C", "sapphire": "base0D", "blue": "base0D", "mauve": "base0E",
+	"pink": "base0E", "flamingo": "base0F",
+}
+
+var base16Line = regexp.MustCompile(`^\s*(base0[0-9A-Fa-f]|scheme|name)\s*:\s*(.*)$`)
+
+// directory of the own themes - not created until something is imported
+func ThemesDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, themesDir), nil
+}
+
+// built-in and own themes - sorted, own ones replace built-ins of the same name
+func ThemeNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	if dir, err := ThemesDir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), ".toml")
+			if _, builtin := presets[name]; !builtin {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colours of a theme - an own theme can build on a built-in one with preset = "<name>"
+func LoadTheme(name string) (Theme, error) {
+	return loadTheme(name, 0)
+}
+
+func loadTheme(name string, depth int) (Theme, error) {
+	if depth > 4 {
+		return Theme{}, fmt.Errorf("theme %q: presets refer to each other in a loop", name)
+	}
+
+	if dir, err := ThemesDir(); err == nil {
+		data, err := os.ReadFile(filepath.Join(dir, name+".toml"))
+		if err == nil {
+			var theme Theme
+			if err := toml.Unmarshal(data, &theme); err != nil {
+				return Theme{}, fmt.Errorf("theme %s: %v", name, decodeError(err))
+			}
+			base := defaultTheme()
+			if theme.Preset != "" && theme.Preset != name {
+				if base, err = loadTheme(theme.Preset, depth+1); err != nil {
+					return Theme{}, err
+				}
+			} else if preset, ok := presets[name]; ok { // an own version of a built-in only changes some colours
+				base = preset
+			}
+			overlayTheme(&base, theme)
+			base.Preset = name
+			return base, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return Theme{}, err
+		}
+	}
+
+	theme, ok := presets[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	theme.Preset = name
+	return theme, nil
+}
+
+// switch to a theme - with an active profile it becomes the theme of the profile
+func (c *Config) UseTheme(name string) error {
+	theme, err := LoadTheme(name)
+	if err != nil {
+		return err
+	}
+
+	c.Theme = theme
+	if c.profile != "" {
+		profile := c.Profiles[c.profile]
+		profile.Theme = theme
+		c.Profiles[c.profile] = profile
+	}
+	return nil
+}
+
+// fill the colours the file does not set from the preset - set is the table of the theme in the file
+func resolvePreset(theme *Theme, set map[string]any, key string) ValidationError {
+	if theme.Preset == "" {
+		return nil
+	}
+	preset, err := LoadTheme(theme.Preset)
+	if err != nil {
+		return ValidationError{{Key: key + ".preset", Msg: err.Error()}}
+	}
+
+	dst := reflect.ValueOf(theme).Elem()
+	src := reflect.ValueOf(preset)
+	for i := range src.NumField() {
+		if _, ok := set[tomlName(dst.Type().Field(i))]; !ok {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return nil
+}
+
+// presets of [theme] and the profile themes
+func resolvePresets(cfg *Config, raw map[string]any) ValidationError {
+	table := func(m map[string]any, key string) map[string]any {
+		t, _ := m[key].(map[string]any)
+		return t
+	}
+
+	errs := resolvePreset(&cfg.Theme, table(raw, "theme"), "theme")
+	profiles := table(raw, "profiles")
+	for name, profile := range cfg.Profiles {
+		errs = append(errs, resolvePreset(&profile.Theme, table(table(profiles, name), "theme"), "profiles."+name+".theme")...)
+		cfg.Profiles[name] = profile
+	}
+	return errs
+}
+
+// only the colours that differ from the preset are written - changing the preset in the file changes them all
+func (t Theme) stored() Theme {
+	if t.Preset == "" {
+		return t
+	}
+	preset, err := LoadTheme(t.Preset)
+	if err != nil {
+		return t
+	}
+
+	dst := reflect.ValueOf(&t).Elem()
+	src := reflect.ValueOf(preset)
+	for i := range src.NumField() {
+		if tomlName(dst.Type().Field(i)) != "preset" && dst.Field(i).String() == src.Field(i).String() {
+			dst.Field(i).SetString("")
+		}
+	}
+	return t
+}
+
+// read a base16 scheme (yaml) and save it as own theme - returns its name and the file it was written to
+// name defaults to the scheme name in the file, an own theme of that name is only replaced with overwrite
+func ImportBase16(path, name string, overwrite bool) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := base16Line.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		values[strings.ToLower(match[1])] = yamlScalar(match[2])
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	if name == "" {
+		name = values["scheme"]
+		if name == "" {
+			name = values["name"]
+		}
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+	name = themeFileName(name)
+	if name == "" {
+		return "", "", errors.New("the theme needs a name")
+	}
+
+	var theme Theme
+	v := reflect.ValueOf(&theme).Elem()
+	for i := range v.NumField() {
+		colour := tomlName(v.Type().Field(i))
+		slot, ok := base16Slots[colour]
+		if !ok {
+			continue
+		}
+		value := strings.TrimPrefix(values[strings.ToLower(slot)], "#")
+		if !hexColor.MatchString("#" + value) {
+			return "", "", fmt.Errorf("%s: %s is missing or not a hex colour", filepath.Base(path), slot)
+		}
+		v.Field(i).SetString("#" + strings.ToLower(value))
+	}
+
+	dir, err := ThemesDir()
+	if err != nil {
+		return "", "", err
+	}
+	target := filepath.Join(dir, name+".toml")
+	if _, err := os.Stat(target); err == nil && !overwrite {
+		return "", "", fmt.Errorf("theme %s already exists (%s) - import it with overwrite to replace it or give it another name", name, target)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", err
+	}
+	data, err := toml.Marshal(theme)
+	if err != nil {
+		return "", "", err
+	}
+	header := fmt.Sprintf("# imported from %s\n", filepath.Base(path))
+	return name, target, atomicfile.WriteFile(target, append([]byte(header), data...), 0644)
+}
+
+// value of a yaml line - quoted or up to a comment
+func yamlScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	value, _, _ = strings.Cut(value, " #")
+	return strings.TrimSpace(value)
+}
+
+// lower case with dashes - "Tomorrow Night" is tomorrow-night
+func themeFileName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ' || r == '.':
+			return '-'
+		}
+		return -1
+	}, name)
+	return strings.Trim(name, "-")
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"twitch-tui/internal/secrets"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// a value in config.toml that can not be used
+type FieldError struct {
+	Line int    // 0 when the key is not in the file
+	Key  string // dotted path like theme.base
+	Msg  string
+}
+
+func (e FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s line %d: %s %s", configFileName, e.Line, e.Key, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s %s", configFileName, e.Key, e.Msg)
+}
+
+// every problem of a config - the file is only used when there are none
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, field := range e {
+		msgs[i] = field.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// check the values toml can not check for us - the errors have no lines yet
+func (c Config) validate() ValidationError {
+	var errs ValidationError
+	add := func(key, format string, args ...any) {
+		errs = append(errs, FieldError{Key: key, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	checkTheme := func(prefix string, theme Theme, allowEmpty bool) {
+		value := reflect.ValueOf(theme)
+		for i := range value.NumField() {
+			color := value.Field(i).String()
+			if tomlName(value.Type().Field(i)) == "preset" { // checked when the preset was resolved
+				continue
+			}
+			if color == "" && allowEmpty { // profile themes only replace the colours they set
+				continue
+			}
+			if !hexColor.MatchString(color) {
+				add(prefix+"."+tomlName(value.Type().Field(i)), "%q is not a hex colour like #ca9ee6", color)
+			}
+		}
+	}
+	checkColor := func(key, color string) {
+		if !hexColor.MatchString(color) {
+			add(key, "%q is not a hex colour like #ca9ee6", color)
+		}
+	}
+	checkURL := func(key, raw string, schemes ...string) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+			add(key, "%q is not a %s url", raw, strings.Join(schemes, " or "))
+		}
+	}
+
+	checkTheme("theme", c.fileView().Theme, false)
+	for _, name := range c.ProfileNames() {
+		checkTheme("profiles."+name+".theme", c.Profiles[name].Theme, true)
+	}
+
+	if err := checkLayout(c.Style.DateFormat); err != "" {
+		add("style.date_format", "%q %s", c.Style.DateFormat, err)
+	}
+
+	if ratio := c.Style.MinContrast; ratio != 0 && (ratio < 1 || ratio > 21) {
+		add("style.min_contrast", "%g has to be 0 (off) or a contrast ratio from 1 to 21", ratio)
+	}
+
+	if c.Badges.Max < 0 {
+		add("badges.max", "%d can not be negative", c.Badges.Max)
+	}
+	for name, glyph := range c.Badges.Glyphs {
+		field := themeField(&Theme{}, strings.ToLower(glyph.Color))
+		if glyph.Color != "" && !hexColor.MatchString(glyph.Color) && (!field.IsValid() || glyph.Color == "preset") {
+			add("badges.glyphs."+name+".color", "%q is neither a hex colour nor a theme colour like mauve", glyph.Color)
+		}
+	}
+
+	checkColor("emotes.twitch.color", c.Emotes.Twitch.Color)
+	checkColor("emotes.sevenTv.color", c.Emotes.SevenTv.Color)
+	checkColor("emotes.bttv.color", c.Emotes.Bttv.Color)
+	checkColor("emotes.ffz.color", c.Emotes.Ffz.Color)
+
+	checkURL("twitch.refresh_api", c.Twitch.RefreshApi, "https", "http")
+	checkURL("helix.base_url", c.Helix.BaseURL, "https", "http")
+	checkURL("eventsub.url", c.EventSub.URL, "wss", "ws")
+	checkURL("eventsub.subscription_url", c.EventSub.SubscriptionURL, "https", "http")
+
+	bits := c.Api.Bits
+	if bits.BitsAmount < 0 {
+		add("api.bits.bits_amount", "%d can not be negative", bits.BitsAmount)
+	}
+	if bits.Enable || bits.Endpoint != "" {
+		checkURL("api.bits.endpoint", bits.Endpoint, "https", "http")
+	}
+
+	if c.Chat.Scrollback < 0 {
+		add("chat.scrollback", "%d can not be negative", c.Chat.Scrollback)
+	}
+	if c.Chat.HistorySize < 0 {
+		add("chat.history_size", "%d can not be negative", c.Chat.HistorySize)
+	}
+	if c.Stream.PollInterval < 0 {
+		add("stream.poll_interval", "%d can not be negative", c.Stream.PollInterval)
+	}
+
+	backends := []string{secrets.BackendAuto, secrets.BackendKeyring, secrets.BackendFile}
+	if !slices.Contains(backends, c.Secrets.Backend) {
+		add("secrets.backend", "%q is not one of %s", c.Secrets.Backend, strings.Join(backends, ", "))
+	}
+
+	for i, plugin := range c.Plugins {
+		if plugin.Enable && plugin.Command == "" {
+			add(fmt.Sprintf("plugins.%d.command", i), "is empty but the plugin %q is enabled", plugin.Name)
+		}
+		if plugin.TimeoutMs < 0 {
+			add(fmt.Sprintf("plugins.%d.timeout_ms", i), "%d can not be negative", plugin.TimeoutMs)
+		}
+	}
+
+	return errs
+}
+
+// a go time layout - formatting the reference time has to change something and parse back
+// returns why it is no layout, empty when it is fine
+func checkLayout(layout string) string {
+	if layout == "" { // no timestamps
+		return ""
+	}
+
+	ref := time.Date(2001, time.March, 4, 20, 31, 42, 0, time.UTC) // no value like the one in the layouts
+
+	formatted := ref.Format(layout)
+	if formatted == layout {
+		return "has no go time layout elements - write 15:04:05 for hours, minutes and seconds"
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return "is no go time layout: " + err.Error()
+	}
+	return ""
+}
+
+func tomlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	return name
+}
+
+// put the line of its key in the file to every error - sorted by line
+func (e ValidationError) withLines(data []byte) ValidationError {
+	lines := keyLines(data)
+	for i := range e {
+		// keys of inline tables are not listed - use the closest parent
+		for key := e[i].Key; key != ""; {
+			if line, ok := lines[key]; ok {
+				e[i].Line = line
+				break
+			}
+			cut := strings.LastIndex(key, ".")
+			if cut < 0 {
+				break
+			}
+			key = key[:cut]
+		}
+	}
+	slices.SortStableFunc(e, func(a, b FieldError) int { return a.Line - b.Line })
+	return e
+}
+
+// line of every key in the file - array tables are counted like plugins.0.name
+func keyLines(data []byte) map[string]int {
+	lines := map[string]int{}
+	arrays := map[string]int{}
+
+	var parser unstable.Parser
+	parser.Reset(data)
+	var table []string
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			var line int
+			table, line = keyPath(&parser, expr.Key())
+			if expr.Kind == unstable.ArrayTable {
+				path := strings.Join(table, ".")
+				table = append(table, fmt.Sprint(arrays[path]))
+				arrays[path]++
+			}
+			lines[strings.Join(table, ".")] = line
+		case unstable.KeyValue:
+			key, line := keyPath(&parser, expr.Key())
+			lines[strings.Join(append(slices.Clone(table), key...), ".")] = line
+		}
+	}
+	return lines
+}
+
+// parts of a dotted key and the line it starts on
+func keyPath(parser *unstable.Parser, it unstable.Iterator) ([]string, int) {
+	var path []string
+	line := 0
+	for it.Next() {
+		if line == 0 {
+			line = parser.Shape(it.Node().Raw).Start.Line
+		}
+		path = append(path, string(it.Node().Data))
+	}
+	return path, line
+}
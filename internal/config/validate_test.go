@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestCheckLayout(t *testing.T) {
+	tests := []struct {
+		layout string
+		ok     bool
+	}{
+		{"", true},
+		{"15:04:05", true},
+		{"15:04", true},
+		{"3:04PM", true},
+		{"2006-01-02 15:04", true},
+		{"[15:04]", true},
+		{"hh:mm:ss", false},
+		{"HH:MM", false},
+		{"time", false},
+		{"05", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			if msg := checkLayout(tt.layout); (msg == "") != tt.ok {
+				t.Errorf("checkLayout(%q) = %q, want ok %v", tt.layout, msg, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	watchDebounce = 200 * time.Millisecond // editors write in several steps - wait until they are done
+	pollInterval  = 2 * time.Second        // when the os gives us no file events
+)
+
+// reload the config whenever config.toml changes on disk
+// every reload is reported on the channel - nil when it was applied, the reason when it was rejected
+func (s *Store) Watch(ctx context.Context) <-chan error {
+	results := make(chan error, 1)
+	path, err := getConfigPath()
+	if err != nil {
+		results <- err
+		return results
+	}
+
+	go func() {
+		changes, err := watchFile(ctx, path)
+		if err != nil { // no inotify & co - look at the file every few seconds instead
+			changes = pollFile(ctx, path)
+		}
+
+		for range changes {
+			changed, err := s.reload(path)
+			if !changed && err == nil {
+				continue
+			}
+			select {
+			case results <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// read config.toml again now - an invalid file keeps the current config
+func (s *Store) Reload() error {
+	path, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	_, err = s.reload(path)
+	return err
+}
+
+// read the file and take it over when it is valid - false when nothing changed since we wrote it
+// the running identity in [twitch] stays, it is only read on start and :account
+func (s *Store) reload(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) { // removed or in the middle of a rename
+			return false, nil
+		}
+		return true, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(data, s.saved) {
+		return false, nil
+	}
+
+	cfg, _, err := parseConfig(data) // older versions are only rewritten on start
+	if err != nil {
+		return true, err
+	}
+	if err := loadSecrets(&cfg); err != nil {
+		return true, err
+	}
+	if err := cfg.UseProfile(s.cfg.ActiveProfile()); err != nil {
+		return true, err
+	}
+
+	cfg.Twitch = s.cfg.Twitch
+	s.saved = data
+	s.cfg = cfg
+	s.notify()
+	return true, nil
+}
+
+// file events of the config - the directory is watched since saving usually replaces the file
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan struct{})
+	go func() {
+		defer watcher.Close()
+		defer close(changes)
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Base(event.Name) == filepath.Base(path) && !event.Has(fsnotify.Chmod) {
+					debounce = time.After(watchDebounce)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-debounce:
+				debounce = nil
+				select {
+				case changes <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// compare size and modification time every few seconds
+func pollFile(ctx context.Context, path string) <-chan struct{} {
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		var last os.FileInfo
+		last, _ = os.Stat(path)
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			select {
+			case changes <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
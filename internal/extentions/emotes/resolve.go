@@ -1,6 +1,8 @@
 package emotes
 
 import (
+	"sort"
+	"sync"
 	"twitch-tui/internal/config"
 
 	irc "github.com/gempir/go-twitch-irc/v4"
@@ -26,3 +28,35 @@ func ResolveEmotes(content string, emotes []*irc.Emote, cfg config.Config, offse
 
 	return content
 }
+
+// all emote codes of the enabled providers - used for tab completion
+func Codes(cfg config.Config) []string {
+	seen := make(map[string]bool)
+	collect := func(cache map[string]string, mu *sync.RWMutex) {
+		mu.RLock()
+		defer mu.RUnlock()
+		for code := range cache {
+			seen[code] = true
+		}
+	}
+
+	if cfg.Emotes.Twitch.Enable {
+		collect(twitchCache, &twitchCacheMu)
+	}
+	if cfg.Emotes.SevenTv.Enable {
+		collect(sevenTvCache, &sevenTvCacheMu)
+	}
+	if cfg.Emotes.Bttv.Enable {
+		collect(bttvCache, &bttvCacheMu)
+	}
+	if cfg.Emotes.Ffz.Enable {
+		collect(ffzCache, &ffzCacheMu)
+	}
+
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
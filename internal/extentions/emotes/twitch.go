@@ -4,11 +4,18 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/lipgloss"
 	irc "github.com/gempir/go-twitch-irc/v4"
 )
 
+// twitch sends no emote list - we remember the ones we have seen in messages
+var (
+	twitchCache   = make(map[string]string)
+	twitchCacheMu sync.RWMutex
+)
+
 // twitch emotes are easy they send you the places of them in the message, so we just need to spliece it
 func addTwitchEmotesLink(text string, emotes []*irc.Emote, theme string, posOffset int) string {
 	type segment struct {
@@ -57,6 +64,7 @@ func addTwitchEmotesLink(text string, emotes []*irc.Emote, theme string, posOffs
 			"https://static-cdn.jtvnw.net/emoticons/v2/%s/default/dark/3.0", //the 3.0 is the meote size
 			seg.id,
 		)
+		rememberTwitchEmote(seg.name, cdnURL)
 		styledName := emoteStyle.Render(seg.name)
 		result.WriteString(fmt.Sprintf("\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\", cdnURL, styledName)) // create clickable hyperlik
 
@@ -67,3 +75,9 @@ func addTwitchEmotesLink(text string, emotes []*irc.Emote, theme string, posOffs
 
 	return result.String()
 }
+
+func rememberTwitchEmote(name, cdnURL string) {
+	twitchCacheMu.Lock()
+	twitchCache[name] = cdnURL
+	twitchCacheMu.Unlock()
+}
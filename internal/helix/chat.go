@@ -0,0 +1,33 @@
+package helix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// chat settings as helix sends them - durations are nil while the mode is off
+type ChatSettings struct {
+	EmoteMode            bool `json:"emote_mode"`
+	FollowerMode         bool `json:"follower_mode"`
+	FollowerModeDuration *int `json:"follower_mode_duration"`
+	SlowMode             bool `json:"slow_mode"`
+	SlowModeWaitTime     *int `json:"slow_mode_wait_time"`
+	SubscriberMode       bool `json:"subscriber_mode"`
+	UniqueChatMode       bool `json:"unique_chat_mode"`
+}
+
+// send only the changed fields - helix answers with all settings
+func (c *Client) UpdateChatSettings(ctx context.Context, broadcasterID, moderatorID string, changes map[string]any) (ChatSettings, error) {
+	var result struct {
+		Data []ChatSettings `json:"data"`
+	}
+	if err := c.Do(ctx, http.MethodPatch, "/chat/settings", moderatorQuery(broadcasterID, moderatorID), changes, &result); err != nil {
+		return ChatSettings{}, err
+	}
+	settings, ok := first(result.Data)
+	if !ok {
+		return ChatSettings{}, errors.New("no chat settings in the answer")
+	}
+	return settings, nil
+}
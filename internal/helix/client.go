@@ -0,0 +1,295 @@
+package helix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.twitch.tv/helix"
+
+	requestTimeout = 10 * time.Second
+	maxRetries     = 3               // 429 answers in a row before we give up
+	maxRateWait    = 1 * time.Minute // never sleep longer than this for the rate limit
+)
+
+// where the client gets its credentials from
+// Refresh is called once when helix answers 401 with the token that was rejected - afterwards the request is sent again
+type TokenSource interface {
+	Token() (accessToken, clientID string)
+	Refresh(ctx context.Context, rejected string) error
+}
+
+// typed client for the twitch helix api
+type Client struct {
+	http *http.Client
+	auth TokenSource
+
+	mu        sync.Mutex
+	baseURL   string
+	remaining int       // requests left in the current rate limit window - -1 when unknown
+	reset     time.Time // when the window refills
+}
+
+func New(baseURL string, auth TokenSource) *Client {
+	c := &Client{
+		http:      &http.Client{Timeout: requestTimeout},
+		auth:      auth,
+		remaining: -1,
+	}
+	c.SetBaseURL(baseURL)
+	return c
+}
+
+// switch the api - an empty url goes back to twitch
+func (c *Client) SetBaseURL(baseURL string) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c.mu.Lock()
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+	c.mu.Unlock()
+}
+
+// send a request - body is sent as json, out is filled from the json answer
+// path is relative to the base url or a full url (eventsub mock servers live elsewhere)
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		resp, sentToken, err := c.send(ctx, method, c.endpoint(path, query), raw)
+		if err != nil {
+			return err
+		}
+		c.trackRateLimit(resp.Header)
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < maxRetries:
+			resp.Body.Close()
+			if err := sleep(ctx, c.retryDelay(resp.Header, attempt)); err != nil {
+				return err
+			}
+			continue
+
+		case resp.StatusCode == http.StatusUnauthorized && !refreshed:
+			apiErr := errorFrom(resp)
+			if strings.Contains(strings.ToLower(apiErr.Message), "missing scope") { // a new token would not have it either
+				return apiErr
+			}
+			if err := c.auth.Refresh(ctx, sentToken); err != nil {
+				return fmt.Errorf("%w (token refresh failed: %v)", apiErr, err)
+			}
+			refreshed = true
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errorFrom(resp)
+		}
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// returns the token the request was sent with - a refresh has to know which one was rejected
+func (c *Client) send(ctx context.Context, method, endpoint string, body []byte) (*http.Response, string, error) {
+	accessToken, clientID := c.auth.Token()
+	if accessToken == "" {
+		return nil, "", ErrNotLoggedIn
+	}
+	if clientID == "" {
+		return nil, "", ErrMissingClientID
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Client-ID", clientID)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	return resp, accessToken, err
+}
+
+func (c *Client) endpoint(path string, query url.Values) string {
+	endpoint := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		c.mu.Lock()
+		endpoint = c.baseURL + path
+		c.mu.Unlock()
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return endpoint
+}
+
+// helix sends the bucket state with every answer
+func (c *Client) trackRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("Ratelimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64)
+
+	c.mu.Lock()
+	c.remaining = remaining
+	c.reset = time.Unix(reset, 0)
+	c.mu.Unlock()
+}
+
+// an empty bucket waits for the refill instead of collecting 429s
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Duration(0)
+	if c.remaining == 0 {
+		wait = min(time.Until(c.reset), maxRateWait)
+		c.remaining = -1 // the answer of the next request tells us again
+	}
+	c.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// wait until the reset helix told us - or back off 1s, 2s, 4s
+func (c *Client) retryDelay(header http.Header, attempt int) time.Duration {
+	if reset, err := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64); err == nil {
+		if wait := time.Until(time.Unix(reset, 0)); wait > 0 {
+			return min(wait, maxRateWait)
+		}
+	}
+	return time.Second << attempt
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// page of a list endpoint
+type page[T any] struct {
+	Data       []T `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+// follow the cursor until all items are there - limit 0 fetches everything
+func Paginate[T any](ctx context.Context, c *Client, path string, query url.Values, limit int) ([]T, error) {
+	query = cloneQuery(query)
+	if query.Get("first") == "" {
+		query.Set("first", "100")
+	}
+
+	var items []T
+	for {
+		var p page[T]
+		if err := c.Do(ctx, http.MethodGet, path, query, nil, &p); err != nil {
+			return items, err
+		}
+		items = append(items, p.Data...)
+
+		if limit > 0 && len(items) >= limit {
+			return items[:limit], nil
+		}
+		if p.Pagination.Cursor == "" || len(p.Data) == 0 {
+			return items, nil
+		}
+		query.Set("after", p.Pagination.Cursor)
+	}
+}
+
+// answers that are a list with a single entry
+func first[T any](items []T) (T, bool) {
+	var zero T
+	if len(items) == 0 {
+		return zero, false
+	}
+	return items[0], true
+}
+
+func cloneQuery(query url.Values) url.Values {
+	clone := make(url.Values, len(query))
+	for key, values := range query {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+var (
+	ErrNotLoggedIn     = errors.New("not logged in - use :login first")
+	ErrMissingClientID = errors.New("missing client ID - use :login first")
+)
+
+// error answer of the helix api
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden {
+		return fmt.Sprintf("%s (%d) - use :login to grant the missing permissions", e.Message, e.Status)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Status)
+}
+
+// read the message helix puts into the body
+func errorFrom(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &result); err == nil && result.Message != "" {
+		message = result.Message
+	}
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &Error{Status: resp.StatusCode, Message: message}
+}
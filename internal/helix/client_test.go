@@ -0,0 +1,257 @@
+package helix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// token source that hands out "fresh" after a refresh and remembers which tokens were rejected
+type fakeTokens struct {
+	mu         sync.Mutex
+	token      string
+	refreshErr error
+	rejected   []string
+}
+
+func (f *fakeTokens) Token() (string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.token, "client-id"
+}
+
+func (f *fakeTokens) Refresh(_ context.Context, rejected string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejected = append(f.rejected, rejected)
+	if f.refreshErr != nil {
+		return f.refreshErr
+	}
+	f.token = "fresh"
+	return nil
+}
+
+func bearer(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func TestDoRefreshesOnUnauthorized(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string // of the 401 answer
+		acceptFresh  bool
+		refreshErr   error
+		wantErr      bool
+		wantRequests int
+		wantRejected []string
+	}{
+		{"refresh and retry", "Invalid OAuth token", true, nil, false, 2, []string{"old"}},
+		{"refreshed token rejected too", "Invalid OAuth token", false, nil, true, 2, []string{"old"}},
+		{"refresh fails", "Invalid OAuth token", true, errors.New("no refresh token"), true, 1, []string{"old"}},
+		{"missing scope", "Missing scope: moderator:manage:banned_users", true, nil, true, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				mu.Unlock()
+				if r.Header.Get("Client-ID") != "client-id" {
+					t.Errorf("Client-ID = %q, want client-id", r.Header.Get("Client-ID"))
+				}
+				if bearer(r) == "fresh" && tt.acceptFresh {
+					w.Write([]byte(`{"data":[{"id":"1"}]}`))
+					return
+				}
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"message": tt.message})
+			}))
+			defer server.Close()
+
+			tokens := &fakeTokens{token: "old", refreshErr: tt.refreshErr}
+			c := New(server.URL, tokens)
+
+			var out page[struct{ ID string }]
+			err := c.Do(context.Background(), http.MethodGet, "/users", nil, nil, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var apiErr *Error
+				if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+					t.Errorf("Do error = %v, want a 401 *Error", err)
+				}
+			} else if len(out.Data) != 1 || out.Data[0].ID != "1" {
+				t.Errorf("decoded %+v, want one user with id 1", out.Data)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+			if !slices.Equal(tokens.rejected, tt.wantRejected) {
+				t.Errorf("Refresh called with %q, want %q", tokens.rejected, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestDoRetriesTooManyRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// a reset in the next second keeps the retry short
+			w.Header().Set("Ratelimit-Remaining", "0")
+			w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Ratelimit-Remaining", "799")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, &fakeTokens{token: "old"})
+	start := time.Now()
+	if err := c.Do(context.Background(), http.MethodPost, "/chat/announcements", nil, map[string]string{"message": "hi"}, nil); err != nil {
+		t.Fatalf("Do error = %v, want nil", err)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("retry took %v - the reset header was not used", elapsed)
+	}
+}
+
+func TestDoGivesUpAfterCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := New(server.URL, &fakeTokens{token: "old"}).Do(ctx, http.MethodGet, "/users", nil, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do error = %v, want the deadline of the context", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		reset    string
+		attempt  int
+		min, max time.Duration
+	}{
+		{"no header", "", 0, time.Second, time.Second},
+		{"backoff doubles", "", 2, 4 * time.Second, 4 * time.Second},
+		{"reset passed", strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), 1, 2 * time.Second, 2 * time.Second},
+		{"reset ahead", strconv.FormatInt(now.Add(30*time.Second).Unix(), 10), 0, 28 * time.Second, 30 * time.Second},
+		{"reset too far", strconv.FormatInt(now.Add(time.Hour).Unix(), 10), 0, maxRateWait, maxRateWait},
+		{"garbage", "soon", 1, 2 * time.Second, 2 * time.Second},
+	}
+
+	c := New("", &fakeTokens{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.reset != "" {
+				header.Set("Ratelimit-Reset", tt.reset)
+			}
+			if got := c.retryDelay(header, tt.attempt); got < tt.min || got > tt.max {
+				t.Errorf("retryDelay = %v, want %v to %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		first        string // page size asked for - empty uses the default
+		limit        int
+		failAt       int // request that fails - 0 for none
+		want         int
+		wantRequests int
+		wantErr      bool
+	}{
+		{"single page", 5, "", 0, 0, 5, 1, false},
+		{"follows the cursor", 7, "3", 0, 0, 7, 3, false},
+		{"empty page after the last", 6, "3", 0, 0, 6, 3, false},
+		{"limit stops early", 10, "3", 4, 0, 4, 2, false},
+		{"limit above total", 4, "3", 10, 0, 4, 2, false},
+		{"empty", 0, "", 0, 0, 0, 1, false},
+		{"error keeps what came", 7, "3", 0, 2, 3, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == tt.failAt {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if r.URL.Query().Get("broadcaster_id") != "42" {
+					t.Errorf("broadcaster_id = %q, want 42", r.URL.Query().Get("broadcaster_id"))
+				}
+				size, _ := strconv.Atoi(r.URL.Query().Get("first"))
+				if tt.first == "" && size != 100 {
+					t.Errorf("first = %d, want the default of 100", size)
+				}
+				from, _ := strconv.Atoi(r.URL.Query().Get("after"))
+				to := min(from+size, tt.total)
+
+				var p page[int]
+				for i := from; i < to; i++ {
+					p.Data = append(p.Data, i)
+				}
+				if to-from == size { // like helix a full page has a cursor even when nothing follows
+					p.Pagination.Cursor = strconv.Itoa(to)
+				}
+				json.NewEncoder(w).Encode(p)
+			}))
+			defer server.Close()
+
+			query := url.Values{"broadcaster_id": {"42"}}
+			if tt.first != "" {
+				query.Set("first", tt.first)
+			}
+			got, err := Paginate[int](context.Background(), New(server.URL, &fakeTokens{token: "old"}), "/moderation/banned", query, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Paginate error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d items, want %d", len(got), tt.want)
+			}
+			for i, item := range got {
+				if item != i {
+					t.Errorf("item %d = %d - pages came out of order", i, item)
+					break
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+			if query.Get("after") != "" {
+				t.Error("Paginate changed the query of the caller")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package helix
+
+import (
+	"context"
+	"net/http"
+)
+
+type EventSubSubscription struct {
+	Type      string            `json:"type"`
+	Version   string            `json:"version"`
+	Condition map[string]string `json:"condition"`
+	Transport EventSubTransport `json:"transport"`
+}
+
+type EventSubTransport struct {
+	Method    string `json:"method"` // websocket
+	SessionID string `json:"session_id"`
+}
+
+// endpoint is a full url so mock servers can be used - empty means the helix one
+func (c *Client) CreateEventSubSubscription(ctx context.Context, endpoint string, sub EventSubSubscription) error {
+	if endpoint == "" {
+		endpoint = "/eventsub/subscriptions"
+	}
+	return c.Do(ctx, http.MethodPost, endpoint, nil, sub, nil)
+}
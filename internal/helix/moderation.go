@@ -0,0 +1,66 @@
+package helix
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Ban struct {
+	UserID   string `json:"user_id"`
+	Duration int    `json:"duration,omitempty"` // seconds - 0 bans permanently
+	Reason   string `json:"reason,omitempty"`
+}
+
+func moderatorQuery(broadcasterID, moderatorID string) url.Values {
+	return url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+}
+
+func (c *Client) BanUser(ctx context.Context, broadcasterID, moderatorID string, ban Ban) error {
+	body := struct {
+		Data Ban `json:"data"`
+	}{ban}
+	return c.Do(ctx, http.MethodPost, "/moderation/bans", moderatorQuery(broadcasterID, moderatorID), body, nil)
+}
+
+func (c *Client) UnbanUser(ctx context.Context, broadcasterID, moderatorID, userID string) error {
+	query := moderatorQuery(broadcasterID, moderatorID)
+	query.Set("user_id", userID)
+	return c.Do(ctx, http.MethodDelete, "/moderation/bans", query, nil, nil)
+}
+
+// without a message id every message of the channel is removed
+func (c *Client) DeleteChatMessages(ctx context.Context, broadcasterID, moderatorID, messageID string) error {
+	query := moderatorQuery(broadcasterID, moderatorID)
+	if messageID != "" {
+		query.Set("message_id", messageID)
+	}
+	return c.Do(ctx, http.MethodDelete, "/moderation/chat", query, nil, nil)
+}
+
+type shieldMode struct {
+	Data []struct {
+		IsActive bool `json:"is_active"`
+	} `json:"data"`
+}
+
+func (c *Client) ShieldMode(ctx context.Context, broadcasterID, moderatorID string) (bool, error) {
+	var result shieldMode
+	if err := c.Do(ctx, http.MethodGet, "/moderation/shield_mode", moderatorQuery(broadcasterID, moderatorID), nil, &result); err != nil {
+		return false, err
+	}
+	return len(result.Data) > 0 && result.Data[0].IsActive, nil
+}
+
+// returns the state helix reports afterwards
+func (c *Client) UpdateShieldMode(ctx context.Context, broadcasterID, moderatorID string, active bool) (bool, error) {
+	var result shieldMode
+	body := map[string]bool{"is_active": active}
+	if err := c.Do(ctx, http.MethodPut, "/moderation/shield_mode", moderatorQuery(broadcasterID, moderatorID), body, &result); err != nil {
+		return false, err
+	}
+	if len(result.Data) == 0 {
+		return active, nil
+	}
+	return result.Data[0].IsActive, nil
+}
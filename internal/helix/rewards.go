@@ -0,0 +1,66 @@
+package helix
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type CustomReward struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Cost  int    `json:"cost"`
+}
+
+type Redemption struct {
+	ID         string    `json:"id"`
+	UserLogin  string    `json:"user_login"`
+	UserInput  string    `json:"user_input"`
+	Status     string    `json:"status"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// redemption states
+const (
+	RedemptionUnfulfilled = "UNFULFILLED"
+	RedemptionFulfilled   = "FULFILLED"
+	RedemptionCanceled    = "CANCELED"
+)
+
+// onlyManageable limits the list to rewards created by our client id
+func (c *Client) CustomRewards(ctx context.Context, broadcasterID string, onlyManageable bool) ([]CustomReward, error) {
+	query := url.Values{"broadcaster_id": {broadcasterID}}
+	if onlyManageable {
+		query.Set("only_manageable_rewards", "true")
+	}
+
+	var result struct {
+		Data []CustomReward `json:"data"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/channel_points/custom_rewards", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// redemptions of a reward with the status - oldest first
+func (c *Client) Redemptions(ctx context.Context, broadcasterID, rewardID, status string, limit int) ([]Redemption, error) {
+	query := url.Values{
+		"broadcaster_id": {broadcasterID},
+		"reward_id":      {rewardID},
+		"status":         {status},
+		"sort":           {"OLDEST"},
+		"first":          {"50"},
+	}
+	return Paginate[Redemption](ctx, c, "/channel_points/custom_rewards/redemptions", query, limit)
+}
+
+func (c *Client) UpdateRedemptionStatus(ctx context.Context, broadcasterID, rewardID, redemptionID, status string) error {
+	query := url.Values{
+		"id":             {redemptionID},
+		"broadcaster_id": {broadcasterID},
+		"reward_id":      {rewardID},
+	}
+	return c.Do(ctx, http.MethodPatch, "/channel_points/custom_rewards/redemptions", query, map[string]string{"status": status}, nil)
+}
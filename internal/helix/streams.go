@@ -0,0 +1,25 @@
+package helix
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+type Stream struct {
+	Type        string    `json:"type"` // "live" or empty on errors
+	Title       string    `json:"title"`
+	GameName    string    `json:"game_name"`
+	ViewerCount int       `json:"viewer_count"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// the stream of a broadcaster - false when they are offline
+func (c *Client) StreamByUserID(ctx context.Context, userID string) (Stream, bool, error) {
+	streams, err := Paginate[Stream](ctx, c, "/streams", url.Values{"user_id": {userID}, "first": {"1"}}, 1)
+	if err != nil {
+		return Stream{}, false, err
+	}
+	stream, ok := first(streams)
+	return stream, ok && stream.Type == "live", nil
+}
@@ -0,0 +1,31 @@
+package helix
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+type User struct {
+	ID          string `json:"id"`
+	Login       string `json:"login"`
+	DisplayName string `json:"display_name"`
+}
+
+// look up users by login names and ids - helix takes up to 100 of both together
+func (c *Client) Users(ctx context.Context, logins, ids []string) ([]User, error) {
+	query := url.Values{"login": logins, "id": ids}
+	return Paginate[User](ctx, c, "/users", query, 0)
+}
+
+func (c *Client) UserByLogin(ctx context.Context, login string) (User, error) {
+	users, err := c.Users(ctx, []string{login}, nil)
+	if err != nil {
+		return User{}, err
+	}
+	user, ok := first(users)
+	if !ok {
+		return User{}, fmt.Errorf("user %s not found", login)
+	}
+	return user, nil
+}
@@ -0,0 +1,162 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"twitch-tui/internal/atomicfile"
+)
+
+const (
+	FileName = "history.json"
+	MinLimit = 50 // lines per channel and kind at least - smaller limits including 0 use it
+)
+
+type Kind string
+
+const (
+	Chat    Kind = "chat"
+	Command Kind = "command"
+)
+
+// what we send per channel - kept separate for chat lines and : commands
+type channelHistory struct {
+	Chat    []string `json:"chat"`
+	Command []string `json:"command"`
+}
+
+type file struct {
+	Channels map[string]*channelHistory `json:"channels"`
+}
+
+// persisted input history - a nil store works but remembers nothing
+type Store struct {
+	path  string
+	limit int
+
+	mu       sync.Mutex
+	channels map[string]*channelHistory
+}
+
+// load the history file - a missing file is just an empty history
+func Open(path string, limit int) (*Store, error) {
+	s := &Store{
+		path:     path,
+		limit:    max(limit, MinLimit),
+		channels: make(map[string]*channelHistory),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, fmt.Errorf("history: read failed: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return s, fmt.Errorf("history: decode failed: %w", err)
+	}
+	for name, ch := range f.Channels {
+		if ch != nil {
+			s.channels[name] = ch
+		}
+	}
+
+	return s, nil
+}
+
+// remember a line and write the history to disk - repeating the last line is ignored
+func (s *Store) Add(channel string, kind Kind, line string) error {
+	if s == nil || line == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.channels[channel]
+	if !ok {
+		ch = &channelHistory{}
+		s.channels[channel] = ch
+	}
+
+	list := ch.list(kind)
+	if len(*list) > 0 && (*list)[len(*list)-1] == line {
+		return nil
+	}
+	*list = append(*list, line)
+	if len(*list) > s.limit {
+		*list = append([]string(nil), (*list)[len(*list)-s.limit:]...)
+	}
+
+	return s.save()
+}
+
+// all lines of a channel - oldest first
+func (s *Store) Entries(channel string, kind Kind) []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.channels[channel]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), *ch.list(kind)...)
+}
+
+// channels we have a history for
+func (s *Store) Channels() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.channels))
+	for name := range s.channels {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// change how many lines are kept per channel and kind - applied on the next add
+func (s *Store) SetLimit(limit int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.limit = max(limit, MinLimit)
+	s.mu.Unlock()
+}
+
+func (c *channelHistory) list(kind Kind) *[]string {
+	if kind == Command {
+		return &c.Command
+	}
+	return &c.Chat
+}
+
+// write the whole history - only readable by us since it holds everything we typed
+// the file is replaced in one step so a crash or a second instance never leaves it cut off
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(file{Channels: s.channels}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("history: encode failed: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("history: write failed: %w", err)
+	}
+	return nil
+}
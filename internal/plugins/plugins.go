@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"twitch-tui/internal/config"
+	"twitch-tui/internal/twitch"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+type ActionKind int
+
+const (
+	ActionSend     ActionKind = iota // send Text as chat message
+	ActionRegister                   // add Command as : command
+	ActionNotice                     // show Text as system message
+)
+
+// something a plugin wants the tui to do
+type Action struct {
+	Plugin  string
+	Kind    ActionKind
+	Text    string
+	Command Command
+}
+
+// runs the configured plugins and fans messages and events out to them
+// a nil manager is valid and does nothing
+type Manager struct {
+	Actions chan Action
+
+	processes []*process
+	budget    time.Duration // time all plugins together get for one batch of messages
+
+	mu      sync.RWMutex
+	channel string
+	user    string
+}
+
+// launch every enabled plugin - they are supervised in the background
+func Start(cfg config.Config) *Manager {
+	m := &Manager{
+		Actions: make(chan Action, 64),
+		channel: cfg.Twitch.Channel,
+		user:    cfg.Twitch.User,
+	}
+
+	for _, pluginCfg := range cfg.Plugins {
+		if !pluginCfg.Enable || pluginCfg.Command == "" {
+			continue
+		}
+		p := newProcess(pluginCfg, m)
+		m.processes = append(m.processes, p)
+		m.budget = max(m.budget, p.timeout)
+		go p.supervise()
+	}
+
+	return m
+}
+
+// remember the channel we are in - plugins get it with every message
+func (m *Manager) SetChannel(channel string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.channel = channel
+	m.mu.Unlock()
+}
+
+// let every plugin look at a batch of messages - the ones a plugin dropped are left out
+// every plugin gets the whole batch at once and all share one deadline, so a slow plugin
+// costs a frame at most once - messages not answered by then are kept as they are
+func (m *Manager) ProcessBatch(msgs []twitch.ChatMessage) []twitch.ChatMessage {
+	if m == nil || len(m.processes) == 0 || len(msgs) == 0 {
+		return msgs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.budget)
+	defer cancel()
+	for _, p := range m.processes {
+		msgs = m.processWith(ctx, p, msgs)
+	}
+	return msgs
+}
+
+// a plugin that fails leaves the messages alone - a missed deadline counts as one missed answer
+func (m *Manager) processWith(ctx context.Context, p *process, msgs []twitch.ChatMessage) []twitch.ChatMessage {
+	requests := make([]*pendingCall, len(msgs))
+	for i, msg := range msgs {
+		if ctx.Err() != nil {
+			break
+		}
+		if req, err := p.request(MethodMessage, MessageParams{Message: m.toWire(msg)}); err == nil {
+			requests[i] = &req
+		}
+	}
+
+	missed := false
+	kept := msgs[:0]
+	for i, msg := range msgs {
+		if requests[i] == nil {
+			kept = append(kept, msg)
+			continue
+		}
+		var result MessageResult
+		if err := p.await(ctx, *requests[i], &result); err != nil {
+			missed = missed || errors.Is(err, errTimeout)
+			kept = append(kept, msg)
+			continue
+		}
+
+		for _, text := range result.Send {
+			m.emit(Action{Plugin: p.cfg.Name, Kind: ActionSend, Text: text})
+		}
+		if result.Drop {
+			continue
+		}
+		if result.Annotation != "" {
+			msg.Annotation = strings.TrimSpace(msg.Annotation + " " + result.Annotation)
+		}
+		if result.Highlight != nil {
+			msg.Highlight = *result.Highlight
+		}
+		kept = append(kept, msg)
+	}
+
+	if missed {
+		p.missedAnswer()
+	}
+	return kept
+}
+
+// tell every plugin about something that happened
+func (m *Manager) Event(kind, text string) {
+	if m == nil {
+		return
+	}
+	for _, p := range m.processes {
+		p.notify(MethodEvent, EventParams{Type: kind, Text: text})
+	}
+}
+
+// run a : command a plugin registered
+func (m *Manager) Execute(plugin, name string, args []string) (ExecuteResult, error) {
+	var result ExecuteResult
+	if m == nil {
+		return result, errors.New("plugins are not running")
+	}
+
+	for _, p := range m.processes {
+		if p.cfg.Name == plugin {
+			err := p.call(MethodExecute, ExecuteParams{Name: name, Args: args}, &result, p.timeout)
+			return result, err
+		}
+	}
+	return result, errors.New("unknown plugin: " + plugin)
+}
+
+// stop all plugins - they will not be restarted
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	for _, p := range m.processes {
+		p.stop()
+	}
+}
+
+// queue an action for the tui - dropped when the tui does not keep up
+func (m *Manager) emit(action Action) {
+	select {
+	case m.Actions <- action:
+	default:
+	}
+}
+
+func (m *Manager) toWire(msg twitch.ChatMessage) Message {
+	m.mu.RLock()
+	channel := m.channel
+	m.mu.RUnlock()
+
+	return Message{
+		ID:        msg.ID,
+		Time:      msg.Time,
+		Channel:   channel,
+		User:      msg.User,
+		UserID:    msg.UserID,
+		Flare:     msg.Flare,
+		Badges:    badgeNames(msg.Badges),
+		Content:   ansi.Strip(msg.Content),
+		Bits:      msg.Bits,
+		Highlight: msg.Highlight,
+	}
+}
+
+func badgeNames(badges []twitch.Badge) []string {
+	names := make([]string, len(badges))
+	for i, badge := range badges {
+		names[i] = badge.String()
+	}
+	return names
+}
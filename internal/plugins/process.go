@@ -0,0 +1,399 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"twitch-tui/internal/config"
+)
+
+const (
+	defaultTimeout = 500 * time.Millisecond
+	initTimeout    = 5 * time.Second
+	maxBackoff     = 30 * time.Second
+	maxRestarts    = 5           // crashes in a row before we give up on a plugin
+	stableRun      = time.Minute // a plugin that ran this long gets its crash count reset
+	maxTimeouts    = 3           // missed answers in a row before the plugin gets killed
+	outQueueSize   = 1024        // a whole chat batch of requests fits
+	maxLineSize    = 1 << 20
+)
+
+var (
+	errNotRunning = errors.New("plugin is not running")
+	errQueueFull  = errors.New("plugin is not reading its input")
+	errTimeout    = errors.New("plugin did not answer in time")
+	errExited     = errors.New("plugin exited")
+)
+
+// one supervised plugin executable
+type process struct {
+	cfg     config.Plugin
+	mgr     *Manager
+	timeout time.Duration
+	quit    chan struct{}
+
+	mu       sync.Mutex
+	running  bool
+	stopped  bool
+	cmd      *exec.Cmd
+	out      chan []byte // lines for the writer of the current run
+	pending  map[int64]chan rpcMessage
+	nextID   int64
+	timeouts int
+}
+
+func newProcess(cfg config.Plugin, mgr *Manager) *process {
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Command
+	}
+
+	return &process{
+		cfg:     cfg,
+		mgr:     mgr,
+		timeout: timeout,
+		quit:    make(chan struct{}),
+	}
+}
+
+// keep the plugin running - restart it with backoff when it dies, give up when it keeps crashing
+func (p *process) supervise() {
+	backoff := time.Second
+	failures := 0
+
+	for {
+		started := time.Now()
+		err := p.run()
+		if p.isStopped() {
+			return
+		}
+
+		if time.Since(started) > stableRun {
+			failures = 0
+			backoff = time.Second
+		}
+		failures++
+		if failures > maxRestarts {
+			p.notice(fmt.Sprintf("Plugin %s crashed %d times in a row - giving up: %v", p.cfg.Name, failures, err))
+			return
+		}
+
+		p.notice(fmt.Sprintf("Plugin %s stopped (%v) - restarting in %s", p.cfg.Name, err, backoff))
+		select {
+		case <-time.After(backoff):
+		case <-p.quit:
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// start the executable and block until it exits
+func (p *process) run() error {
+	cmd := exec.Command(p.cfg.Command, p.cfg.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	out := make(chan []byte, outQueueSize)
+	done := make(chan struct{})
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.out = out
+	p.pending = make(map[int64]chan rpcMessage)
+	p.timeouts = 0
+	p.running = true
+	p.mu.Unlock()
+
+	go writeLines(stdin, out, done)
+	go p.initialize()
+
+	p.read(stdout)
+	err = cmd.Wait()
+
+	p.mu.Lock()
+	p.running = false
+	for id, ch := range p.pending { // nobody is going to answer those anymore
+		close(ch)
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+	close(done)
+
+	if err == nil {
+		err = errExited
+	}
+	return err
+}
+
+// ask the plugin for its commands
+func (p *process) initialize() {
+	p.mgr.mu.RLock()
+	params := InitializeParams{
+		App:      "twitch-tui",
+		Protocol: ProtocolVersion,
+		Channel:  p.mgr.channel,
+		User:     p.mgr.user,
+	}
+	p.mgr.mu.RUnlock()
+
+	var result InitializeResult
+	if err := p.call(MethodInitialize, params, &result, initTimeout); err != nil {
+		p.notice(fmt.Sprintf("Plugin %s initialize failed: %v", p.cfg.Name, err))
+		return
+	}
+
+	for _, command := range result.Commands {
+		p.mgr.emit(Action{Plugin: p.cfg.Name, Kind: ActionRegister, Command: command})
+	}
+}
+
+// read the answers and notifications of the plugin until its stdout closes
+func (p *process) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			p.notice(fmt.Sprintf("Plugin %s sent invalid json: %v", p.cfg.Name, err))
+			continue
+		}
+
+		if msg.Method != "" {
+			p.handleRequest(msg)
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+
+		p.mu.Lock()
+		ch, ok := p.pending[*msg.ID]
+		delete(p.pending, *msg.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+// things the plugin asks us to do on its own
+func (p *process) handleRequest(msg rpcMessage) {
+	var err error
+	switch msg.Method {
+	case MethodSend:
+		var params SendParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil && params.Text != "" {
+			p.mgr.emit(Action{Plugin: p.cfg.Name, Kind: ActionSend, Text: params.Text})
+		}
+	case MethodRegister:
+		var params Command
+		if err = json.Unmarshal(msg.Params, &params); err == nil && params.Name != "" {
+			p.mgr.emit(Action{Plugin: p.cfg.Name, Kind: ActionRegister, Command: params})
+		}
+	case MethodNotice:
+		var params NoticeParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil && params.Text != "" {
+			p.mgr.emit(Action{Plugin: p.cfg.Name, Kind: ActionNotice, Text: p.cfg.Name + ": " + params.Text})
+		}
+	default:
+		err = fmt.Errorf("unknown method %s", msg.Method)
+	}
+
+	if msg.ID == nil { // notification - nobody waits for an answer
+		return
+	}
+	reply := rpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{}`)}
+	if err != nil {
+		reply = rpcMessage{JSONRPC: "2.0", ID: msg.ID, Error: &rpcError{Code: -32602, Message: err.Error()}}
+	}
+	p.write(reply)
+}
+
+// send a request and wait for the answer - a plugin that misses too many answers gets restarted
+func (p *process) call(method string, params any, result any, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := p.request(method, params)
+	if err != nil {
+		return err
+	}
+	if err := p.await(ctx, req, result); err != nil {
+		if errors.Is(err, errTimeout) {
+			p.missedAnswer()
+		}
+		return err
+	}
+	return nil
+}
+
+// a request on its way to the plugin - see await
+type pendingCall struct {
+	id int64
+	ch chan rpcMessage
+}
+
+// send a request without waiting for the answer
+func (p *process) request(method string, params any) (pendingCall, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return pendingCall{}, err
+	}
+
+	p.mu.Lock()
+	if !p.running {
+		p.mu.Unlock()
+		return pendingCall{}, errNotRunning
+	}
+	p.nextID++
+	req := pendingCall{id: p.nextID, ch: make(chan rpcMessage, 1)}
+	p.pending[req.id] = req.ch
+	p.mu.Unlock()
+
+	if err := p.write(rpcMessage{JSONRPC: "2.0", ID: &req.id, Method: method, Params: raw}); err != nil {
+		p.forget(req.id)
+		return pendingCall{}, err
+	}
+	return req, nil
+}
+
+// wait for the answer of a request until ctx is done - timeouts are counted by the caller
+func (p *process) await(ctx context.Context, req pendingCall, result any) error {
+	var resp rpcMessage
+	var ok bool
+	select {
+	case resp, ok = <-req.ch: // an answer that is already there wins over a deadline that passed
+	default:
+		select {
+		case resp, ok = <-req.ch:
+		case <-ctx.Done():
+			p.forget(req.id)
+			return errTimeout
+		}
+	}
+	if !ok {
+		return errExited
+	}
+
+	p.mu.Lock()
+	p.timeouts = 0
+	p.mu.Unlock()
+	if resp.Error != nil {
+		return fmt.Errorf("plugin error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// send a notification - dropped when the plugin is not running
+func (p *process) notify(method string, params any) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	_ = p.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// queue a line for the writer - never blocks the caller
+func (p *process) write(msg rpcMessage) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running {
+		return errNotRunning
+	}
+
+	select {
+	case p.out <- append(line, '\n'):
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+func (p *process) forget(id int64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+// count timeouts - a plugin that stopped answering gets killed and the supervisor restarts it
+func (p *process) missedAnswer() {
+	p.mu.Lock()
+	p.timeouts++
+	hung := p.timeouts >= maxTimeouts && p.cmd != nil && p.cmd.Process != nil
+	if hung {
+		p.timeouts = 0
+		_ = p.cmd.Process.Kill()
+	}
+	p.mu.Unlock()
+
+	if hung {
+		p.notice(fmt.Sprintf("Plugin %s did not answer %d times in a row - killed", p.cfg.Name, maxTimeouts))
+	}
+}
+
+func (p *process) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	close(p.quit)
+	if p.running && p.cmd != nil && p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+func (p *process) isStopped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopped
+}
+
+func (p *process) notice(text string) {
+	p.mgr.emit(Action{Plugin: p.cfg.Name, Kind: ActionNotice, Text: text})
+}
+
+// write the queued lines to the plugin until the run ends
+func writeLines(w io.WriteCloser, lines chan []byte, done chan struct{}) {
+	defer w.Close()
+	for {
+		select {
+		case line := <-lines:
+			if _, err := w.Write(line); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// the wire format is json-rpc 2.0 - one json object per line on stdin / stdout
+const (
+	ProtocolVersion = 1
+
+	// host -> plugin
+	MethodInitialize = "initialize"      // request - answered with InitializeResult
+	MethodMessage    = "chat.message"    // request - answered with MessageResult
+	MethodExecute    = "command.execute" // request - answered with ExecuteResult
+	MethodEvent      = "system.event"    // notification
+
+	// plugin -> host (notifications)
+	MethodSend     = "chat.send"
+	MethodRegister = "command.register"
+	MethodNotice   = "system.message"
+)
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type InitializeParams struct {
+	App      string `json:"app"`
+	Protocol int    `json:"protocol"`
+	Channel  string `json:"channel"`
+	User     string `json:"user"`
+}
+
+type InitializeResult struct {
+	Commands []Command `json:"commands"`
+}
+
+// : command provided by a plugin
+type Command struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Usage       string `json:"usage"`
+}
+
+// chat message as plugins see it - content without colors and hyperlinks
+type Message struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Channel   string    `json:"channel"`
+	User      string    `json:"user"`
+	UserID    string    `json:"user_id"`
+	Flare     string    `json:"flare"`
+	Badges    []string  `json:"badges"` // name/version like subscriber/3024
+	Content   string    `json:"content"`
+	Bits      int       `json:"bits"`
+	Highlight string    `json:"highlight"`
+}
+
+type MessageParams struct {
+	Message Message `json:"message"`
+}
+
+// what a plugin wants to happen with a message - the zero value keeps it as it is
+type MessageResult struct {
+	Drop       bool     `json:"drop"`
+	Annotation string   `json:"annotation"`
+	Highlight  *string  `json:"highlight"` // hex color - an empty string removes the highlight
+	Send       []string `json:"send"`
+}
+
+type EventParams struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type ExecuteParams struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+}
+
+type ExecuteResult struct {
+	Output string   `json:"output"`
+	Send   []string `json:"send"`
+}
+
+type SendParams struct {
+	Text string `json:"text"`
+}
+
+type NoticeParams struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,276 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	fileMagic        = "TTS1" // format version - salt, nonce and the sealed json follow
+	unreadableSuffix = ".unreadable"
+	saltSize         = 16
+	keySize          = 32 // aes-256
+	pbkdf2Iterations = 600_000
+)
+
+// secrets sealed with aes-gcm in a 0600 file - the key comes from the passphrase through pbkdf2
+type fileStore struct {
+	path       string
+	passphrase string
+	legacy     []string // keys of older versions - a file sealed with one is sealed again with the passphrase
+
+	mu   sync.Mutex
+	salt []byte // of the key below - derived once, pbkdf2 is slow on purpose
+	key  []byte
+}
+
+func newFileStore(path, passphrase string) *fileStore {
+	return &fileStore{path: path, passphrase: passphrase}
+}
+
+func (f *fileStore) Name() string {
+	return "encrypted file " + f.path
+}
+
+func (f *fileStore) Get(key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fileStore) Set(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values, err := f.loadForWrite()
+	if err != nil {
+		return err
+	}
+	values[key] = value
+	return f.save(values)
+}
+
+func (f *fileStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values, err := f.loadForWrite()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+	delete(values, key)
+	return f.save(values)
+}
+
+// read and open the file - a missing file is an empty store
+func (f *fileStore) load() (map[string]string, error) {
+	values := make(map[string]string)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < len(fileMagic)+saltSize || string(data[:len(fileMagic)]) != fileMagic {
+		return nil, fmt.Errorf("%s is not a secrets file", f.path)
+	}
+	data = data[len(fileMagic):]
+	salt, sealed := data[:saltSize], data[saltSize:]
+
+	gcm, err := f.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", f.path)
+	}
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(fileMagic))
+	resealed := false
+	for _, passphrase := range f.legacy {
+		if err == nil {
+			break
+		}
+		plain, err = openLegacy(passphrase, salt, nonce, sealed)
+		resealed = err == nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s - the passphrase in %s, the machine or the user changed", ErrUnreadable, f.path, PassphraseEnv)
+	}
+
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, err
+	}
+	if resealed {
+		if err := f.save(values); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// like load, but a file that can not be decrypted is moved aside and a new one started
+// the tokens in it are lost to us anyway - :login puts new ones in
+func (f *fileStore) loadForWrite() (map[string]string, error) {
+	values, err := f.load()
+	if !errors.Is(err, ErrUnreadable) {
+		return values, err
+	}
+	if err := os.Rename(f.path, f.path+unreadableSuffix); err != nil {
+		return nil, err
+	}
+	f.salt, f.key = nil, nil
+	return make(map[string]string), nil
+}
+
+// open a file sealed with a key of an older version - the cached key stays the one of the passphrase
+func openLegacy(passphrase string, salt, nonce, sealed []byte) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, sealed, []byte(fileMagic))
+}
+
+// seal and write through a temp file - the old file stays until the new one is complete
+func (f *fileStore) save(values map[string]string) error {
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	salt := f.salt
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	gcm, err := f.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fileMagic)
+	buf.Write(salt)
+	buf.Write(nonce)
+	buf.Write(gcm.Seal(nil, nonce, plain, []byte(fileMagic)))
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".secrets-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // gone after the rename anyway
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// aes-gcm with the key for the salt - derived again only when the salt changed
+func (f *fileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if f.key == nil || !bytes.Equal(f.salt, salt) {
+		key, err := pbkdf2.Key(sha256.New, f.passphrase, salt, pbkdf2Iterations, keySize)
+		if err != nil {
+			return nil, err
+		}
+		f.salt = bytes.Clone(salt)
+		f.key = key
+	}
+
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// stable per machine and user - keeps the file useless when copied elsewhere, not a replacement for a passphrase
+// only ids that do not change with the network or a rename - the host name once made files unreadable
+func machineSecret() (string, error) {
+	var parts []string
+	if id := machineID(); id != "" {
+		parts = append(parts, id)
+	}
+	if u, err := user.Current(); err == nil {
+		parts = append(parts, u.Uid)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("cannot derive a machine key - set %s", PassphraseEnv)
+	}
+	return "twitch-tui:" + strings.Join(parts, ":"), nil
+}
+
+// the key of files written before the host name was dropped from it
+func legacyMachineSecrets() []string {
+	var parts []string
+	if id := machineID(); id != "" {
+		parts = append(parts, id)
+	}
+	if host, err := os.Hostname(); err == nil {
+		parts = append(parts, host)
+	}
+	if u, err := user.Current(); err == nil {
+		parts = append(parts, u.Uid, u.Username)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return []string{"twitch-tui:" + strings.Join(parts, ":")}
+}
+
+func machineID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if id, err := os.ReadFile(path); err == nil {
+			return strings.TrimSpace(string(id))
+		}
+	}
+	return ""
+}
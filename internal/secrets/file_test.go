@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		written    string   // passphrase the file was sealed with
+		passphrase string   // of the store that opens it
+		legacy     []string // older keys the store knows
+		wantErr    error
+	}{
+		{"same passphrase", "a", "a", nil, nil},
+		{"legacy key", "old", "new", []string{"old"}, nil},
+		{"second legacy key", "older", "new", []string{"old", "older"}, nil},
+		{"unknown key", "other", "new", []string{"old"}, ErrUnreadable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := newFileStore(path, tt.written).Set("twitch.oauth", "token"); err != nil {
+				t.Fatal(err)
+			}
+
+			store := newFileStore(path, tt.passphrase)
+			store.legacy = tt.legacy
+			got, err := store.Get("twitch.oauth")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != "token" {
+				t.Errorf("Get = %q, want token", got)
+			}
+
+			// the file is sealed with the passphrase now - no legacy key needed any more
+			if _, err := newFileStore(path, tt.passphrase).Get("twitch.oauth"); err != nil {
+				t.Errorf("Get without the legacy keys = %v, want the file sealed again", err)
+			}
+		})
+	}
+}
+
+func TestFileStoreUnreadableIsReplaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := newFileStore(path, "old").Set("twitch.oauth", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newFileStore(path, "new")
+	if _, err := store.Get("twitch.oauth"); !errors.Is(err, ErrUnreadable) {
+		t.Fatalf("Get error = %v, want ErrUnreadable", err)
+	}
+	if err := store.Set("twitch.oauth", "fresh"); err != nil {
+		t.Fatalf("Set on an unreadable file = %v, want a new file", err)
+	}
+	if got, err := store.Get("twitch.oauth"); err != nil || got != "fresh" {
+		t.Errorf("Get = %q, %v, want fresh", got, err)
+	}
+	if _, err := newFileStore(path+unreadableSuffix, "old").Get("twitch.oauth"); err != nil {
+		t.Errorf("the old file was not kept: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+const keyringService = "twitch-tui"
+
+// macos keychain, windows credential manager or the secret service over dbus
+type keyringStore struct {
+	service string
+}
+
+func newKeyringStore() *keyringStore {
+	return &keyringStore{service: keyringService}
+}
+
+func (k *keyringStore) Name() string {
+	return "system keyring"
+}
+
+// a missing entry means the keyring answered - anything else means there is none (headless, no dbus)
+func (k *keyringStore) probe() error {
+	_, err := keyring.Get(k.service, "probe")
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+func (k *keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(k.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (k *keyringStore) Set(key, value string) error {
+	return keyring.Set(k.service, key, value)
+}
+
+func (k *keyringStore) Delete(key string) error {
+	err := keyring.Delete(k.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	BackendAuto    = "auto"    // keyring when one answers - the encrypted file otherwise
+	BackendKeyring = "keyring" // system keyring only
+	BackendFile    = "file"    // encrypted file only
+
+	FileName      = "secrets.enc"
+	PassphraseEnv = "TWITCH_TUI_PASSPHRASE" // without it the file key is derived from the machine
+)
+
+var (
+	ErrNotFound   = errors.New("secret not found")
+	ErrUnreadable = errors.New("secrets can not be decrypted") // the next write starts a new file
+)
+
+// where credentials live - keys are plain names like "twitch.oauth"
+type Store interface {
+	Name() string
+	Get(key string) (string, error) // ErrNotFound when the key was never set
+	Set(key, value string) error
+	Delete(key string) error // deleting a missing key is no error
+}
+
+// open the store of the backend - dir holds the fallback file
+func Open(backend, dir string) (Store, error) {
+	file := func() (Store, error) {
+		passphrase := os.Getenv(PassphraseEnv)
+		var legacy []string
+		if passphrase == "" {
+			var err error
+			if passphrase, err = machineSecret(); err != nil {
+				return nil, err
+			}
+			legacy = legacyMachineSecrets()
+		}
+		store := newFileStore(filepath.Join(dir, FileName), passphrase)
+		store.legacy = legacy
+		return store, nil
+	}
+
+	switch backend {
+	case BackendKeyring:
+		store := newKeyringStore()
+		if err := store.probe(); err != nil {
+			return nil, fmt.Errorf("system keyring unavailable: %w", err)
+		}
+		return store, nil
+	case BackendFile:
+		return file()
+	case BackendAuto, "":
+		if store := newKeyringStore(); store.probe() == nil {
+			return store, nil
+		}
+		return file()
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q (auto, keyring or file)", backend)
+	}
+}
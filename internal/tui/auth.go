@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"twitch-tui/internal/config"
+	"twitch-tui/internal/twitch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// token state and manual refresh - the service validates and refreshes on its own
+func authCommands() []commandDef {
+	return []commandDef{
+		{
+			Name:        "auth",
+			Description: "show or refresh the twitch token",
+			Subcommands: []commandDef{
+				{
+					Name:        "status",
+					Description: "expiry, scopes and the last validation of the token",
+					Handle:      handleAuthStatus,
+				},
+				{
+					Name:        "refresh",
+					Description: "refresh the token now",
+					Handle:      handleAuthRefresh,
+				},
+			},
+		},
+		{
+			Name:        "account",
+			Description: "list the profiles or reconnect as another one - default is the [twitch] account",
+			Args:        []argSpec{{Name: "profile", Optional: true, Complete: completeProfiles}},
+			Examples:    []string{":account", ":account bot"},
+			Handle:      handleAccountCommand,
+		},
+	}
+}
+
+func handleAuthStatus(m *Model, _ []string) (tea.Cmd, error) {
+	m.handleScroll(formatSystemMessage(formatTokenStatus(m.twitch.TokenStatus(), time.Now())))
+	return nil, nil
+}
+
+func handleAuthRefresh(m *Model, _ []string) (tea.Cmd, error) {
+	status := m.twitch.TokenStatus()
+	if !status.LoggedIn {
+		return nil, errors.New("not logged in - use :login first")
+	}
+	if !status.CanRefresh {
+		return nil, errors.New("no refresh token - use :login again")
+	}
+
+	current := m.twitch.AccessToken()
+	return func() tea.Msg {
+		if err := m.twitch.RenewToken(current); err != nil {
+			return noticeMsg("Refresh failed: " + err.Error())
+		}
+		return nil // the service reports the new token itself
+	}, nil
+}
+
+func handleAccountCommand(m *Model, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		m.handleScroll(formatSystemMessage(m.accountList()))
+		return nil, nil
+	}
+
+	name := args[0]
+	if _, ok := m.config.Profiles[name]; !ok && name == defaultProfile {
+		name = ""
+	}
+	if name == m.config.ActiveProfile() {
+		return nil, fmt.Errorf("already using %s", profileLabel(name))
+	}
+	err := m.store.Update(func(cfg *config.Config) error {
+		return cfg.UseProfile(name)
+	})
+	if err != nil && m.store.Get().ActiveProfile() != name { // the profile is not in the config
+		return nil, err
+	}
+	if err != nil {
+		m.handleScroll(formatSystemMessage("Failed to save config: " + err.Error()))
+	}
+
+	cfg := m.store.Get()
+	m.applyConfig(cfg)
+	m.filter = ""
+	m.replyTo = nil
+	m.messages.Reset()
+	m.scroll = 0
+	m.refreshViewport()
+	m.plugins.SetChannel(cfg.Twitch.Channel)
+	m.plugins.Event("channel.join", cfg.Twitch.Channel)
+
+	m.twitch.SwitchAccount()
+	return func() tea.Msg {
+		m.twitch.ConnectAccount()
+		user := cfg.Twitch.User
+		if user == "" {
+			user = "anonymous"
+		}
+		return noticeMsg(fmt.Sprintf("Switched to %s as %s", profileLabel(cfg.ActiveProfile()), user))
+	}, nil
+}
+
+// name :account takes for the [twitch] account
+const defaultProfile = "default"
+
+func profileLabel(name string) string {
+	if name == "" {
+		return "the default account"
+	}
+	return "profile " + name
+}
+
+func (m *Model) accountList() string {
+	var sb strings.Builder
+	sb.WriteString("Accounts (:account <name> to switch):")
+	names := append([]string{""}, m.config.ProfileNames()...)
+	for _, name := range names {
+		marker := "  "
+		if name == m.config.ActiveProfile() {
+			marker = "* "
+		}
+
+		label, user := name, m.config.Profiles[name].User
+		if name == "" {
+			label = defaultProfile
+			user = m.config.Twitch.User
+			if m.config.ActiveProfile() != "" {
+				user = "" // overlaid by the profile - the file has the real one
+			}
+		}
+		if user != "" {
+			label += " - " + user
+		}
+		sb.WriteString("\n  " + marker + label)
+	}
+	return sb.String()
+}
+
+func completeProfiles(m *Model, _ []string) []string {
+	return append([]string{defaultProfile}, m.config.ProfileNames()...)
+}
+
+func formatTokenStatus(status twitch.TokenStatus, now time.Time) string {
+	if !status.LoggedIn {
+		return "Auth: not logged in - use :login"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Auth:")
+	switch {
+	case status.ValidatedAt.IsZero():
+		sb.WriteString("\n  Token: not validated yet")
+	case !status.Valid:
+		sb.WriteString("\n  Token: invalid - " + status.Err)
+	case status.ExpiresAt.IsZero():
+		sb.WriteString("\n  Token: valid, does not expire")
+	default:
+		fmt.Fprintf(&sb, "\n  Token: valid for %s", twitch.FormatUptime(status.ExpiresAt.Sub(now)))
+	}
+	if status.User != "" {
+		sb.WriteString("\n  User: " + status.User)
+	}
+	if !status.ValidatedAt.IsZero() {
+		fmt.Fprintf(&sb, "\n  Validated: %s ago", twitch.FormatUptime(now.Sub(status.ValidatedAt)))
+	}
+
+	refresh := "\n  Refresh: automatic"
+	switch {
+	case !status.CanRefresh:
+		refresh = "\n  Refresh: not possible - no refresh token, use :login when the token expires"
+	case !status.RefreshedAt.IsZero():
+		refresh += fmt.Sprintf(", last %s ago", twitch.FormatUptime(now.Sub(status.RefreshedAt)))
+	}
+	sb.WriteString(refresh)
+	if status.Valid && status.Err != "" { // validation is fine but the last refresh was not
+		sb.WriteString("\n  Last error: " + status.Err)
+	}
+
+	if status.Valid {
+		if missing := status.MissingScopes(); len(missing) > 0 {
+			sb.WriteString("\n  Missing scopes: " + strings.Join(missing, " ") + " - use :login to grant them")
+		}
+	}
+	return sb.String()
+}
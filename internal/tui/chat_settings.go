@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"twitch-tui/internal/twitch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type chatSettingsMsg struct{}
+
+const defaultSlowMode = 30 // seconds - used when :slow toggles it on
+
+// commands for the chat modes - without a value they toggle
+func chatSettingsCommands() []commandDef {
+	state := argSpec{Name: "state", Help: "toggles without a value", Optional: true, Choices: []string{"on", "off"}}
+
+	return []commandDef{
+		{
+			Name:        "slow",
+			Description: "slow mode - seconds between messages of a user",
+			Args:        []argSpec{{Name: "seconds", Help: "3-120 or off - toggles without a value", Optional: true}},
+			Examples:    []string{":slow 10", ":slow off"},
+			Handle:      handleSlowCommand,
+		},
+		{
+			Name:        "subonly",
+			Description: "only subscribers can chat",
+			Args:        []argSpec{state},
+			Handle: toggleMode("Sub-only mode", func(s twitch.ChatSettings) bool { return s.SubOnly },
+				func(t *twitch.Service, on bool) error { return t.SetSubOnly(on) }),
+		},
+		{
+			Name:        "emoteonly",
+			Description: "only emotes can be sent",
+			Args:        []argSpec{state},
+			Handle: toggleMode("Emote-only mode", func(s twitch.ChatSettings) bool { return s.EmoteOnly },
+				func(t *twitch.Service, on bool) error { return t.SetEmoteOnly(on) }),
+		},
+		{
+			Name:        "followers",
+			Description: "only followers can chat - optionally after following for a while",
+			Args:        []argSpec{{Name: "duration", Help: "plain numbers are minutes, e.g. 10, 1h, 1w, 0 or off - toggles without a value", Optional: true}},
+			Examples:    []string{":followers", ":followers 10m", ":followers off"},
+			Handle:      handleFollowersCommand,
+		},
+		{
+			Name:        "unique",
+			Description: "messages must be unique (r9k)",
+			Args:        []argSpec{state},
+			Handle: toggleMode("Unique chat", func(s twitch.ChatSettings) bool { return s.Unique },
+				func(t *twitch.Service, on bool) error { return t.SetUniqueChat(on) }),
+		},
+		{
+			Name:        "shield",
+			Description: "shield mode",
+			Args:        []argSpec{state},
+			Handle: toggleMode("Shield mode", func(s twitch.ChatSettings) bool { return s.Shield },
+				func(t *twitch.Service, on bool) error { return t.SetShieldMode(on) }),
+		},
+	}
+}
+
+// handler for the on / off modes - without a state the current one is flipped
+func toggleMode(label string, current func(twitch.ChatSettings) bool, set func(*twitch.Service, bool) error) commandHandler {
+	return func(m *Model, args []string) (tea.Cmd, error) {
+		on := !current(m.twitch.ChatSettings())
+		if len(args) == 1 {
+			on = args[0] == "on"
+		}
+
+		return m.moderationCmd(label+" "+onOffLabel(on), func() error {
+			return set(m.twitch, on)
+		}), nil
+	}
+}
+
+func handleSlowCommand(m *Model, args []string) (tea.Cmd, error) {
+	seconds := defaultSlowMode
+	switch {
+	case len(args) == 0:
+		if m.twitch.ChatSettings().SlowSeconds > 0 {
+			seconds = 0
+		}
+	case args[0] == "off":
+		seconds = 0
+	default:
+		value, err := strconv.Atoi(strings.TrimSuffix(args[0], "s"))
+		if err != nil || value < twitch.MinSlowMode || value > twitch.MaxSlowMode {
+			return nil, fmt.Errorf("invalid seconds %q (use %d-%d or off)", args[0], twitch.MinSlowMode, twitch.MaxSlowMode)
+		}
+		seconds = value
+	}
+
+	label := "Slow mode off"
+	if seconds > 0 {
+		label = fmt.Sprintf("Slow mode %ds", seconds)
+	}
+	return m.moderationCmd(label, func() error {
+		return m.twitch.SetSlowMode(seconds)
+	}), nil
+}
+
+func handleFollowersCommand(m *Model, args []string) (tea.Cmd, error) {
+	on := !m.twitch.ChatSettings().FollowersOnly
+	minutes := 0
+	if len(args) == 1 {
+		on = args[0] != "off"
+		if on {
+			var err error
+			if minutes, err = parseFollowersDuration(args[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	label := "Followers-only mode off"
+	if on {
+		label = "Followers-only mode on"
+		if minutes > 0 {
+			label += " (" + formatMinutes(minutes) + ")"
+		}
+	}
+	return m.moderationCmd(label, func() error {
+		return m.twitch.SetFollowersOnly(on, minutes)
+	}), nil
+}
+
+// plain numbers are minutes - everything else goes through the timeout parser
+func parseFollowersDuration(value string) (int, error) {
+	if isDigits(value) {
+		minutes, _ := strconv.Atoi(value)
+		if minutes < 0 || minutes > twitch.MaxFollowersMode {
+			return 0, fmt.Errorf("invalid minutes %q (use 0-%d or a duration like 1h)", value, twitch.MaxFollowersMode)
+		}
+		return minutes, nil
+	}
+
+	duration, err := parseTimeout(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < time.Minute {
+		return 0, fmt.Errorf("invalid duration %q (at least 1m)", value)
+	}
+	return int(duration / time.Minute), nil
+}
+
+// the active modes for the header
+func (m Model) chatModes() []string {
+	s := m.twitch.ChatSettings()
+
+	var modes []string
+	if s.SlowSeconds > 0 {
+		modes = append(modes, fmt.Sprintf("slow %ds", s.SlowSeconds))
+	}
+	if s.FollowersOnly {
+		label := "followers"
+		if s.FollowersMinutes > 0 {
+			label += " " + formatMinutes(s.FollowersMinutes)
+		}
+		modes = append(modes, label)
+	}
+	if s.SubOnly {
+		modes = append(modes, "sub-only")
+	}
+	if s.EmoteOnly {
+		modes = append(modes, "emote-only")
+	}
+	if s.Unique {
+		modes = append(modes, "unique")
+	}
+	if s.Shield {
+		modes = append(modes, "shield")
+	}
+	return modes
+}
+
+func waitForChatSettings(sub chan twitch.ChatSettings) tea.Cmd {
+	return func() tea.Msg {
+		<-sub
+		return chatSettingsMsg{}
+	}
+}
+
+// 90 -> 1h30m, 1440 -> 1d
+func formatMinutes(minutes int) string {
+	days, hours, mins := minutes/(24*60), minutes/60%24, minutes%60
+
+	var sb strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&sb, "%dd", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&sb, "%dh", hours)
+	}
+	if mins > 0 || sb.Len() == 0 {
+		fmt.Fprintf(&sb, "%dm", mins)
+	}
+	return sb.String()
+}
+
+func onOffLabel(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"testing"
+	"twitch-tui/internal/twitch"
+)
+
+func TestParseFollowersDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"10", 10, false},
+		{"129600", twitch.MaxFollowersMode, false},
+		{"1h", 60, false},
+		{"90s", 1, false},
+		{"2d", 2 * 24 * 60, false},
+		{"1w", 7 * 24 * 60, false},
+		{"129601", 0, true},
+		{"-5", 0, true},
+		{"30s", 0, true},
+		{"3w", 0, true},
+		{"", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFollowersDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFollowersDuration(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFollowersDuration(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
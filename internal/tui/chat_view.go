@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"strings"
+
+	"twitch-tui/internal/twitch"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// extra lines rendered above the visible window so small scrolls hit the cache
+const renderMargin = 20
+
+// add a message and redraw the chat
+func (m *Model) handleScroll(msg twitch.ChatMessage) {
+	m.pushMessage(msg)
+	m.refreshViewport()
+}
+
+// add a whole batch of messages but only redraw once
+func (m *Model) handleBatch(batch []twitch.ChatMessage) {
+	for _, msg := range batch {
+		m.pushMessage(msg)
+	}
+	m.refreshViewport()
+}
+
+// add a message to the buffer - when the user scrolled up or uses the cursor keep the view where it is
+func (m *Model) pushMessage(msg twitch.ChatMessage) {
+	entry := m.messages.Push(msg)
+	if (m.scroll > 0 || m.cursor != 0) && m.matchesFilter(entry.msg) {
+		m.scroll += len(m.renderEntry(entry))
+	}
+}
+
+// rebuild the visible window - scroll gets clamped to the available lines
+func (m *Model) refreshViewport() {
+	lines, scroll := m.visibleLines()
+	m.scroll = scroll
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.viewport.GotoTop()
+}
+
+// scroll the chat by n lines - positive is up (older messages)
+func (m *Model) scrollBy(n int) {
+	m.scroll = max(m.scroll+n, 0)
+	m.refreshViewport()
+}
+
+// handle the pager keys in view mode
+func (m *Model) handleViewportKey(msg tea.KeyMsg) {
+	keys := m.viewport.KeyMap
+	height := max(m.viewport.Height, 1)
+
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.scrollBy(1)
+	case key.Matches(msg, keys.Down):
+		m.scrollBy(-1)
+	case key.Matches(msg, keys.PageUp):
+		m.scrollBy(height)
+	case key.Matches(msg, keys.PageDown):
+		m.scrollBy(-height)
+	case key.Matches(msg, keys.HalfPageUp):
+		m.scrollBy(height / 2)
+	case key.Matches(msg, keys.HalfPageDown):
+		m.scrollBy(-height / 2)
+	}
+}
+
+// walk back from the newest message until the window (plus margin) is filled
+// only the messages that can be seen get rendered - the rest of the history is never touched
+func (m Model) visibleLines() ([]string, int) {
+	height := m.viewport.Height
+	need := m.scroll + height + renderMargin
+
+	var chunks []*chatEntry // newest first
+	count := 0
+	for i := m.messages.Len() - 1; i >= 0 && count < need; i-- {
+		entry := m.messages.At(i)
+		if !m.matchesFilter(entry.msg) {
+			continue
+		}
+		chunks = append(chunks, entry)
+		count += len(m.renderEntry(entry))
+	}
+
+	// clamp the scroll when we ran out of messages
+	scroll := min(m.scroll, max(count-height, 0))
+
+	// the gutter is added here and not cached - the cursor moves way more often than the width changes
+	styles := m.getStyles()
+	from, to := m.selection()
+	all := make([]string, 0, count)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		gutter := m.gutter(chunks[i].seq, from, to, styles)
+		for _, line := range chunks[i].lines {
+			all = append(all, gutter+line)
+		}
+	}
+
+	end := len(all) - scroll
+	start := max(end-height, 0)
+	return all[start:end], scroll
+}
+
+// the rendered lines of a message - only formats again when width or theme changed
+func (m Model) renderEntry(entry *chatEntry) []string {
+	key := m.renderKey()
+	if entry.lines == nil || entry.key != key {
+		entry.lines = strings.Split(strings.TrimSuffix(m.formatMessage(entry.msg), "\n"), "\n")
+		entry.key = key
+	}
+	return entry.lines
+}
+
+func (m Model) renderKey() renderKey {
+	return renderKey{
+		width:  m.width,
+		theme:  m.config.Theme,
+		style:  m.config.Style,
+		badges: m.badgeKey,
+	}
+}
+
+// check the message against the find filter
+func (m Model) matchesFilter(msg twitch.ChatMessage) bool {
+	if m.filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(msg.Content), strings.ToLower(m.filter))
+}
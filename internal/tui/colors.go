@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"twitch-tui/internal/config"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+const maxNameColors = 4096 // adjusted colours kept - the cache starts over when it is full
+
+// everything an adjusted name colour depends on
+type nameColorKey struct {
+	color string
+	theme config.Theme
+	style config.Style
+}
+
+// the colour a user name is drawn in - moved to the palette and made readable on the background as configured
+func (m Model) nameColor(color string) lipgloss.Color {
+	key := nameColorKey{color: color, theme: m.config.Theme, style: m.config.Style}
+	if adjusted, ok := m.nameColors[key]; ok {
+		return lipgloss.Color(adjusted)
+	}
+
+	adjusted := adjustNameColor(color, m.config.Theme, m.config.Style)
+	if m.nameColors != nil {
+		if len(m.nameColors) >= maxNameColors {
+			clear(m.nameColors)
+		}
+		m.nameColors[key] = adjusted
+	}
+	return lipgloss.Color(adjusted)
+}
+
+// colours that are no hex colour are left alone
+func adjustNameColor(color string, theme config.Theme, style config.Style) string {
+	c, err := colorful.Hex(color)
+	if err != nil {
+		return color
+	}
+	background, err := colorful.Hex(theme.Base)
+	if err != nil {
+		return color
+	}
+
+	if style.PaletteNames {
+		c = nearestPaletteColor(c, theme)
+	}
+	if style.MinContrast > 0 {
+		c = ensureContrast(c, background, style.MinContrast)
+	}
+	return c.Hex()
+}
+
+// the accent colour of the theme that looks the most alike
+func nearestPaletteColor(c colorful.Color, theme config.Theme) colorful.Color {
+	palette := []string{
+		theme.Lavender, theme.Blue, theme.Sapphire, theme.Sky, theme.Teal, theme.Green, theme.Yellow,
+		theme.Peach, theme.Maroon, theme.Red, theme.Mauve, theme.Pink, theme.Flamingo, theme.Rosewater,
+	}
+
+	nearest, best := c, -1.0
+	for _, hex := range palette {
+		candidate, err := colorful.Hex(hex)
+		if err != nil {
+			continue
+		}
+		if distance := c.DistanceCIEDE2000(candidate); best < 0 || distance < best {
+			nearest, best = candidate, distance
+		}
+	}
+	return nearest
+}
+
+// lighten or darken the colour until it has the wcag contrast ratio to the background
+// hue and chroma stay - only the lightness moves as little as needed
+func ensureContrast(c, background colorful.Color, ratio float64) colorful.Color {
+	if contrastRatio(c, background) >= ratio {
+		return c
+	}
+
+	white, black := colorful.Color{R: 1, G: 1, B: 1}, colorful.Color{}
+	lighten := contrastRatio(white, background) >= contrastRatio(black, background)
+
+	h, chroma, l := c.Hcl()
+	lo, hi := l, 1.0 // lightness range the answer is in - the readable end moves towards the colour
+	if !lighten {
+		lo, hi = 0, l
+	}
+	for range 20 {
+		mid := (lo + hi) / 2
+		readable := contrastRatio(hclColor(h, chroma, mid), background) >= ratio
+		if readable == lighten {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	if lighten {
+		return hclColor(h, chroma, hi)
+	}
+	return hclColor(h, chroma, lo)
+}
+
+// rounded to what the hex colour can hold - the contrast is checked on what gets drawn
+func hclColor(h, chroma, l float64) colorful.Color {
+	c, _ := colorful.Hex(colorful.Hcl(h, chroma, l).Clamped().Hex())
+	return c
+}
+
+// wcag contrast ratio - 1 for the same colours up to 21 for black on white
+func contrastRatio(a, b colorful.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func relativeLuminance(c colorful.Color) float64 {
+	r, g, b := c.LinearRgb()
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+func hex(t *testing.T, s string) colorful.Color {
+	t.Helper()
+	c, err := colorful.Hex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"#000000", "#ffffff", 21},
+		{"#ffffff", "#000000", 21},
+		{"#303446", "#303446", 1},
+		{"#ffffff", "#ffffff", 1},
+		{"#777777", "#ffffff", 4.48},
+		{"#ff0000", "#ffffff", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" on "+tt.b, func(t *testing.T) {
+			if got := contrastRatio(hex(t, tt.a), hex(t, tt.b)); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("contrastRatio = %.3f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureContrast(t *testing.T) {
+	const dark, light = "#303446", "#eff1f5" // catppuccin frappe and latte
+
+	tests := []struct {
+		name       string
+		color      string
+		background string
+		ratio      float64
+	}{
+		{"dark blue on dark", "#0000ff", dark, 4.5},
+		{"black on dark", "#000000", dark, 4.5},
+		{"dark red on dark", "#8b0000", dark, 7},
+		{"yellow on light", "#ffff00", light, 4.5},
+		{"white on light", "#ffffff", light, 4.5},
+		{"light green on light", "#90ee90", light, 7},
+		{"readable stays", "#a6d189", dark, 4.5},
+		{"readable stays on light", "#4c4f69", light, 4.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, background := hex(t, tt.color), hex(t, tt.background)
+			got := ensureContrast(c, background, tt.ratio)
+
+			if ratio := contrastRatio(got, background); ratio < tt.ratio {
+				t.Errorf("ensureContrast(%s) = %s with ratio %.2f, want at least %g", tt.color, got.Hex(), ratio, tt.ratio)
+			}
+			if contrastRatio(c, background) >= tt.ratio && got != c {
+				t.Errorf("ensureContrast(%s) = %s, want the readable colour unchanged", tt.color, got.Hex())
+			}
+		})
+	}
+}
+
+func TestEnsureContrastUnreachable(t *testing.T) {
+	// 21 only works for black on white - the colour ends up as readable as it gets
+	background := hex(t, "#303446")
+	got := ensureContrast(hex(t, "#0000ff"), background, 21)
+	if ratio := contrastRatio(got, background); ratio < contrastRatio(hex(t, "#0000ff"), background) {
+		t.Errorf("ensureContrast = %s with ratio %.2f, want it no less readable than before", got.Hex(), ratio)
+	}
+}
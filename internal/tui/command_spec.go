@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// one argument of a command - drives validation, the usage line and tab completion
+type argSpec struct {
+	Name     string
+	Help     string
+	Optional bool
+	Rest     bool             // takes all the remaining words
+	Raw      bool             // with Rest - the handler gets the remaining text as typed, runs of spaces included, as one arg
+	Choices  []string         // allowed values (case insensitive) - also the completion candidates
+	Complete commandCompleter // candidates when the values are not fixed
+}
+
+// usage line like :config emotes <provider> <state>
+func (d commandDef) usage(path string) string {
+	parts := []string{":" + path}
+	if len(d.Subcommands) > 0 {
+		names := make([]string, 0, len(d.Subcommands))
+		for _, sub := range d.Subcommands {
+			names = append(names, sub.Name)
+		}
+		parts = append(parts, "<"+strings.Join(names, "|")+">")
+	}
+
+	for _, arg := range d.Args {
+		name := arg.Name
+		if len(arg.Choices) > 0 {
+			name = strings.Join(arg.Choices, "|")
+		}
+		if arg.Rest {
+			name += "..."
+		}
+		if arg.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// check the args against the spec - every command reports problems the same way
+func (d commandDef) validate(path string, args []string) error {
+	required := 0
+	rest := false
+	for _, arg := range d.Args {
+		if !arg.Optional {
+			required++
+		}
+		rest = rest || arg.Rest
+	}
+
+	switch {
+	case len(args) < required:
+		return fmt.Errorf("missing %s. Usage: %s", d.Args[len(args)].Name, d.usage(path))
+	case len(args) > len(d.Args) && !rest:
+		return fmt.Errorf("too many arguments. Usage: %s", d.usage(path))
+	}
+
+	for i, value := range args {
+		if i >= len(d.Args) {
+			break
+		}
+		arg := d.Args[i]
+		if len(arg.Choices) > 0 && !slices.Contains(arg.Choices, strings.ToLower(value)) {
+			return fmt.Errorf("invalid %s %q (use %s). Usage: %s", arg.Name, value, strings.Join(arg.Choices, ", "), d.usage(path))
+		}
+	}
+
+	return nil
+}
+
+// find a subcommand by name or alias
+func (d commandDef) subcommand(name string) (commandDef, bool) {
+	name = strings.ToLower(name)
+	for _, sub := range d.Subcommands {
+		if sub.Name == name || slices.Contains(sub.Aliases, name) {
+			return sub, true
+		}
+	}
+	return commandDef{}, false
+}
+
+// walk down the subcommands, validate the args and run the handler
+// raw is the input after the command name as it was typed - see argSpec.Raw
+func (m *Model) runCommand(def commandDef, path string, args []string, raw string) (tea.Cmd, error) {
+	if len(def.Subcommands) > 0 {
+		if len(args) == 0 && def.Handle == nil { // a bare group shows its help
+			m.handleScroll(formatSystemMessage(commandHelp(def, path)))
+			return nil, nil
+		}
+		if len(args) > 0 {
+			if sub, ok := def.subcommand(args[0]); ok {
+				return m.runCommand(sub, path+" "+sub.Name, args[1:], afterWords(raw, 1))
+			}
+			if def.Handle == nil {
+				return nil, fmt.Errorf("unknown %s subcommand: %s. Usage: %s", path, args[0], def.usage(path))
+			}
+		}
+	}
+
+	if err := def.validate(path, args); err != nil {
+		return nil, err
+	}
+	if n := len(def.Args) - 1; n >= 0 && def.Args[n].Rest && def.Args[n].Raw && len(args) > n {
+		args = append(args[:n:n], afterWords(raw, n))
+	}
+	return def.Handle(m, args)
+}
+
+// the text after the first n words - spaces inside it are kept
+func afterWords(s string, n int) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	for range n {
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		s = strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+	}
+	return s
+}
+
+// completion candidates for the next argument - follows the subcommands like runCommand
+func completeArgs(m *Model, def commandDef, args []string) []string {
+	if len(def.Subcommands) > 0 {
+		if len(args) == 0 {
+			names := make([]string, 0, len(def.Subcommands))
+			for _, sub := range def.Subcommands {
+				names = append(names, sub.Name)
+			}
+			if def.Handle != nil { // the group takes arguments of its own too
+				names = append(names, completeArgs(m, commandDef{Args: def.Args}, nil)...)
+			}
+			return names
+		}
+		if sub, ok := def.subcommand(args[0]); ok {
+			return completeArgs(m, sub, args[1:])
+		}
+	}
+
+	if len(def.Args) == 0 {
+		return nil
+	}
+
+	idx := len(args)
+	if idx >= len(def.Args) {
+		if !def.Args[len(def.Args)-1].Rest {
+			return nil
+		}
+		idx = len(def.Args) - 1
+	}
+
+	arg := def.Args[idx]
+	if len(arg.Choices) > 0 {
+		return arg.Choices
+	}
+	if arg.Complete != nil {
+		return arg.Complete(m, args)
+	}
+	return nil
+}
+
+// full help of a command - usage, description, arguments, subcommands and examples
+func commandHelp(def commandDef, path string) string {
+	var sb strings.Builder
+	sb.WriteString(def.usage(path))
+	if def.Description != "" {
+		sb.WriteString(" — " + def.Description)
+	}
+	if len(def.Aliases) > 0 {
+		sb.WriteString("\n  aliases: :" + strings.Join(def.Aliases, ", :"))
+	}
+
+	for _, arg := range def.Args {
+		line := "\n  " + arg.Name
+		if arg.Optional {
+			line += " (optional)"
+		}
+		if arg.Help != "" {
+			line += " — " + arg.Help
+		}
+		if len(arg.Choices) > 0 {
+			line += " (" + strings.Join(arg.Choices, ", ") + ")"
+		}
+		sb.WriteString(line)
+	}
+
+	for _, sub := range def.Subcommands {
+		subPath := path + " " + sub.Name
+		sb.WriteString("\n  " + sub.usage(subPath))
+		if sub.Description != "" {
+			sb.WriteString(" — " + sub.Description)
+		}
+		for _, example := range sub.Examples {
+			sb.WriteString("\n      e.g. " + example)
+		}
+	}
+
+	for _, example := range def.Examples {
+		sb.WriteString("\n  e.g. " + example)
+	}
+
+	return sb.String()
+}
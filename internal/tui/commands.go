@@ -3,21 +3,30 @@ package tui
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"twitch-tui/internal/config"
-	"twitch-tui/internal/extentions/emotes"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type commandHandler func(m *Model, args []string) (tea.Cmd, error)
 
+// returns the candidates for the next argument - args are the ones already typed
+type commandCompleter func(m *Model, args []string) []string
+
 type commandDef struct {
-	Name    string
-	Aliases []string
-	Usage   string
-	Handle  commandHandler
+	Name        string
+	Aliases     []string
+	Description string
+	Args        []argSpec
+	Examples    []string
+	Subcommands []commandDef
+	Handle      commandHandler
 }
 
 type loginCompleteMsg struct {
@@ -38,11 +47,11 @@ func (m *Model) executeCommand(input string) tea.Cmd {
 
 	def, ok := commandRegistry[name]
 	if !ok {
-		m.handleScroll(formatSystemMessage(fmt.Sprintf("Unknown command: %s", name)))
+		m.handleScroll(formatSystemMessage(fmt.Sprintf("Unknown command: %s (see :help)", name)))
 		return nil
 	}
 
-	cmd, err := def.Handle(m, args)
+	cmd, err := m.runCommand(def, def.Name, args, afterWords(strings.TrimSpace(input), 1))
 	if err != nil {
 		m.handleScroll(formatSystemMessage(err.Error()))
 		return nil
@@ -51,51 +60,135 @@ func (m *Model) executeCommand(input string) tea.Cmd {
 	return cmd
 }
 
-// list of all the commands we hande
-// consist of long name, short handle, help text, function
-var commandRegistry = func() map[string]commandDef {
-	commands := []commandDef{
+// all the commands we handle - by name and alias
+var (
+	commandRegistry = make(map[string]commandDef)
+	commandOrder    []string // names in the order they got registered - for :help
+)
+
+func init() {
+	for _, cmd := range slices.Concat(builtinCommands(), authCommands(), themeCommands(), moderationCommands(), redemptionCommands(), chatSettingsCommands()) {
+		registerCommand(cmd)
+	}
+}
+
+// add a command under its name and aliases
+func registerCommand(cmd commandDef) {
+	if _, ok := commandRegistry[cmd.Name]; !ok {
+		commandOrder = append(commandOrder, cmd.Name)
+	}
+	commandRegistry[cmd.Name] = cmd
+	for _, alias := range cmd.Aliases {
+		commandRegistry[alias] = cmd
+	}
+}
+
+// list of the commands we ship
+// consist of long name, short handle, help text, args, function
+func builtinCommands() []commandDef {
+	onOff := []string{"enable", "disable"}
+
+	return []commandDef{
 		{
-			Name:    "login",
-			Aliases: []string{"l"},
-			Usage:   ":login [client_id]",
-			Handle:  handleLoginCommand,
+			Name:        "help",
+			Aliases:     []string{"h"},
+			Description: "list the commands or show the help of one",
+			Args:        []argSpec{{Name: "command", Optional: true, Complete: completeCommandNames}},
+			Examples:    []string{":help", ":help config"},
+			Handle:      handleHelpCommand,
 		},
 		{
-			Name:    "join",
-			Aliases: []string{"j"},
-			Usage:   ":join <channel>",
-			Handle:  handleJoinCommand,
+			Name:        "login",
+			Aliases:     []string{"l"},
+			Description: "authenticate with twitch using the device code flow",
+			Args:        []argSpec{{Name: "client_id", Help: "defaults to the client id from the config", Optional: true}},
+			Handle:      handleLoginCommand,
 		},
 		{
-			Name:    "find",
-			Aliases: []string{"f"},
-			Usage:   ":find <string>",
-			Handle:  handleFindCommand,
+			Name:        "join",
+			Aliases:     []string{"j"},
+			Description: "switch to another channel",
+			Args:        []argSpec{{Name: "channel", Complete: completeChannels}},
+			Examples:    []string{":join mychannel"},
+			Handle:      handleJoinCommand,
 		},
 		{
-			Name:    "config",
-			Aliases: []string{"cfg"},
-			Usage:   ":config [reload | api enable/disable | emotes enable/disable]",
-			Handle:  handleConfigCommand,
+			Name:        "say",
+			Aliases:     []string{"s"},
+			Description: "send the text as a chat message",
+			Args:        []argSpec{{Name: "text", Rest: true}},
+			Examples:    []string{":say !uptime"},
+			Handle:      handleSayCommand,
 		},
 		{
-			Name:    "quit",
-			Aliases: []string{"q"},
-			Usage:   ":quit",
-			Handle:  handleQuitCommand,
+			Name:        "find",
+			Aliases:     []string{"f"},
+			Description: "only show messages containing the text - without text the filter is cleared",
+			Args:        []argSpec{{Name: "text", Optional: true, Rest: true}},
+			Examples:    []string{":find hello there", ":find"},
+			Handle:      handleFindCommand,
+		},
+		{
+			Name:        "config",
+			Aliases:     []string{"cfg"},
+			Description: "manage the configuration",
+			Subcommands: []commandDef{
+				{
+					Name:        "reload",
+					Description: "reload config from disk",
+					Handle:      handleConfigReload,
+				},
+				{
+					Name:        "get",
+					Description: "show the value of a key",
+					Args:        []argSpec{{Name: "key", Help: "dotted path like theme.base", Complete: completeConfigKeys}},
+					Examples:    []string{":config get style.date_format"},
+					Handle:      handleConfigGet,
+				},
+				{
+					Name:        "set",
+					Description: "change a key - it is saved and applied right away",
+					Args: []argSpec{
+						{Name: "key", Help: "dotted path like theme.base", Complete: completeSettableKeys},
+						{Name: "value", Help: "text, number or true / false - quotes are optional", Rest: true, Raw: true, Complete: completeConfigValue},
+					},
+					Examples: []string{":config set theme.base #1e1e2e", ":config set style.date_format 15:04", ":config set emotes.bttv.enable true"},
+					Handle:   handleConfigSet,
+				},
+				{
+					Name:        "list",
+					Description: "show all keys with their values",
+					Args:        []argSpec{{Name: "section", Optional: true, Complete: completeConfigSections}},
+					Examples:    []string{":config list theme"},
+					Handle:      handleConfigList,
+				},
+				{
+					Name:        "api",
+					Description: "enable or disable the bits API",
+					Args:        []argSpec{{Name: "state", Choices: onOff}},
+					Examples:    []string{":config api enable"},
+					Handle:      handleConfigApi,
+				},
+				{
+					Name:        "emotes",
+					Description: "enable or disable the emotes of a provider",
+					Args: []argSpec{
+						{Name: "provider", Choices: []string{"twitch", "7tv", "bttv", "ffz"}},
+						{Name: "state", Choices: onOff},
+					},
+					Examples: []string{":config emotes 7tv enable"},
+					Handle:   handleConfigEmotes,
+				},
+			},
+		},
+		{
+			Name:        "quit",
+			Aliases:     []string{"q"},
+			Description: "exit the application",
+			Handle:      handleQuitCommand,
 		},
 	}
-
-	reg := make(map[string]commandDef, len(commands)*2)
-	for _, cmd := range commands {
-		reg[cmd.Name] = cmd
-		for _, alias := range cmd.Aliases {
-			reg[alias] = cmd
-		}
-	}
-	return reg
-}()
+}
 
 func parseCommand(input string) (string, []string, error) {
 	trimmed := strings.TrimSpace(input)
@@ -121,10 +214,6 @@ func parseCommand(input string) (string, []string, error) {
 
 // calls twitch login and updates the config
 func handleLoginCommand(m *Model, args []string) (tea.Cmd, error) {
-	if len(args) > 1 {
-		return nil, errors.New("Usage: :login [client_id]")
-	}
-
 	clientID := m.config.Twitch.ClientID
 	if len(args) == 1 {
 		clientID = strings.TrimSpace(args[0])
@@ -150,13 +239,9 @@ func handleLoginCommand(m *Model, args []string) (tea.Cmd, error) {
 
 // calls the twitch channel connect and update the config - also clear viewport
 func handleJoinCommand(m *Model, args []string) (tea.Cmd, error) {
-	if len(args) < 1 {
-		return nil, errors.New("Usage: :join <channel>")
-	}
-
 	channel := strings.TrimPrefix(args[0], "#")
 	if channel == "" {
-		return nil, errors.New("Usage: :join <channel>")
+		return nil, errors.New("missing channel. Usage: :join <channel>")
 	}
 
 	if m.state == stateInputChannel {
@@ -166,27 +251,27 @@ func handleJoinCommand(m *Model, args []string) (tea.Cmd, error) {
 		m.textInput.Blur()
 		m.twitch.CurrentChannel = channel
 		m.twitch.ChannelID = ""
-		m.config.Twitch.Channel = channel
-		m.config.Twitch.ChannelID = ""
-		m.config.Twitch.UserID = m.twitch.UserID
 		m.filter = ""
-		m.messages = nil
-		m.refreshViewport()
-		if err := config.UpdateConfig(m.config); err != nil {
-			m.handleScroll(formatSystemMessage(fmt.Sprintf("Failed to save config: %v", err)))
-		}
-		return tea.Batch(m.connectCmd(), waitForChatMsg(m.twitch.MsgChan)), nil
+		m.messages.Reset()
+		m.scroll = 0
+		m.updateConfig(func(cfg *config.Config) {
+			cfg.Twitch.Channel = channel
+			cfg.Twitch.ChannelID = ""
+		})
+		m.plugins.SetChannel(channel)
+		m.plugins.Event("channel.join", channel)
+		return tea.Batch(m.connectCmd(), waitForChatBatch(m.twitch.MsgChan, m.plugins, time.Time{})), nil
 	}
 
-	m.config.Twitch.Channel = channel
-	m.config.Twitch.ChannelID = ""
-	m.config.Twitch.UserID = m.twitch.UserID
 	m.filter = ""
-	m.messages = nil
-	m.refreshViewport()
-	if err := config.UpdateConfig(m.config); err != nil {
-		m.handleScroll(formatSystemMessage(fmt.Sprintf("Failed to save config: %v", err)))
-	}
+	m.messages.Reset()
+	m.scroll = 0
+	m.updateConfig(func(cfg *config.Config) {
+		cfg.Twitch.Channel = channel
+		cfg.Twitch.ChannelID = ""
+	})
+	m.plugins.SetChannel(channel)
+	m.plugins.Event("channel.join", channel)
 	return m.switchChannelCmd(channel), nil
 }
 
@@ -198,6 +283,7 @@ func handleFindCommand(m *Model, args []string) (tea.Cmd, error) {
 		m.filter = strings.TrimSpace(strings.Join(args, " "))
 	}
 
+	m.scroll = 0
 	m.refreshViewport()
 	return nil, nil
 }
@@ -207,113 +293,176 @@ func handleQuitCommand(m *Model, args []string) (tea.Cmd, error) {
 	return tea.Quit, nil
 }
 
-func handleConfigCommand(m *Model, args []string) (tea.Cmd, error) {
-	if len(args) == 0 {
-		help := "Config commands:\n" +
-			"  :config reload                                  — reload config from disk\n" +
-			"  :config api enable                              — enable bits API\n" +
-			"  :config api disable                             — disable bits API\n" +
-			"  :config emotes twitch|7tv|bttv|ffz enable       — enable twitch|7tv|bttv|ffz emotes\n" +
-			"  :config emotes twitch|7tv|bttv|ffz disable      — disable twitch|7tv|bttv|ffz emotes"
-		m.handleScroll(formatSystemMessage(help))
+// without a command list all of them - otherwise the full help of that command
+func handleHelpCommand(m *Model, args []string) (tea.Cmd, error) {
+	if len(args) == 1 {
+		name := strings.ToLower(strings.TrimPrefix(args[0], ":"))
+		def, ok := commandRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown command: %s", name)
+		}
+		m.handleScroll(formatSystemMessage(commandHelp(def, def.Name)))
 		return nil, nil
 	}
 
-	switch strings.ToLower(args[0]) {
-	case "reload":
-		newCfg := config.Load()
-		newCfg.Twitch = m.config.Twitch
-		m.config = newCfg
-		m.twitch.UpdateConfig(newCfg)
-		if err := config.UpdateConfig(newCfg); err != nil {
-			m.handleScroll(formatSystemMessage(fmt.Sprintf("Failed to save config: %v", err)))
+	var sb strings.Builder
+	sb.WriteString("Commands (:help <command> for details):")
+	for _, name := range commandOrder {
+		def := commandRegistry[name]
+		line := "\n  " + def.usage(def.Name)
+		if def.Description != "" {
+			line += " — " + def.Description
 		}
-		m.handleScroll(formatSystemMessage("Config reloaded"))
+		sb.WriteString(line)
+	}
+	m.handleScroll(formatSystemMessage(sb.String()))
+	return nil, nil
+}
 
-	case "api":
-		if len(args) < 2 {
-			return nil, errors.New("Usage: :config api enable|disable")
-		}
-		switch strings.ToLower(args[1]) {
-		case "enable":
-			m.config.Api.Bits.Enable = true
-		case "disable":
-			m.config.Api.Bits.Enable = false
-		default:
-			return nil, fmt.Errorf("Unknown option: %s (use enable or disable)", args[1])
-		}
-		m.twitch.UpdateConfig(m.config)
-		if err := config.UpdateConfig(m.config); err != nil {
-			m.handleScroll(formatSystemMessage(fmt.Sprintf("Failed to save config: %v", err)))
+// read config.toml again - the account in use stays
+func handleConfigReload(m *Model, args []string) (tea.Cmd, error) {
+	if err := m.store.Reload(); err != nil {
+		return nil, fmt.Errorf("Config not reloaded: %v", err)
+	}
+	m.applyConfig(m.store.Get())
+	m.handleScroll(formatSystemMessage("Config reloaded"))
+	return nil, nil
+}
+
+func handleConfigGet(m *Model, args []string) (tea.Cmd, error) {
+	value, err := m.config.Get(args[0])
+	if err != nil {
+		return nil, err
+	}
+	m.handleScroll(formatSystemMessage(args[0] + " = " + value))
+	return nil, nil
+}
+
+// set a key through the store - a value of the wrong type or an invalid one keeps the old config
+func handleConfigSet(m *Model, args []string) (tea.Cmd, error) {
+	key, value := args[0], args[1] // the value as typed - see argSpec.Raw
+	err := m.store.Update(func(cfg *config.Config) error {
+		return cfg.Set(key, value)
+	})
+	m.applyConfig(m.store.Get())
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ = m.config.Get(key)
+	m.handleScroll(formatSystemMessage(key + " = " + value))
+	return nil, nil
+}
+
+func handleConfigList(m *Model, args []string) (tea.Cmd, error) {
+	section := ""
+	if len(args) == 1 {
+		section = strings.ToLower(args[0])
+		if !slices.Contains(config.Sections(), section) {
+			return nil, fmt.Errorf("unknown section %q (use %s)", section, strings.Join(config.Sections(), ", "))
 		}
-		m.handleScroll(formatSystemMessage(fmt.Sprintf("Bits API %sd", args[1])))
+	}
 
-	case "emotes":
-		if len(args) < 3 {
-			return nil, errors.New("Usage: :config emotes twitch|7tv|bttv|ffz enable|disable")
+	var sb strings.Builder
+	sb.WriteString("Config:")
+	for _, key := range config.Keys() {
+		if section != "" && !strings.HasPrefix(key, section+".") {
+			continue
 		}
+		value, _ := m.config.Get(key)
+		sb.WriteString("\n  " + key + " = " + value)
+	}
+	m.handleScroll(formatSystemMessage(sb.String()))
+	return nil, nil
+}
 
+func handleConfigApi(m *Model, args []string) (tea.Cmd, error) {
+	state := strings.ToLower(args[0])
+	m.updateConfig(func(cfg *config.Config) {
+		cfg.Api.Bits.Enable = state == "enable"
+	})
+	m.handleScroll(formatSystemMessage(fmt.Sprintf("Bits API %sd", state)))
+	return nil, nil
+}
+
+func handleConfigEmotes(m *Model, args []string) (tea.Cmd, error) {
+	provider := strings.ToLower(args[0])
+	state := strings.ToLower(args[1])
+
+	enable := state == "enable"
+	m.updateConfig(func(cfg *config.Config) {
 		// map of the enable / disable emotes since all are the same
 		emotesConfig := map[string]*bool{
-			"twitch": &m.config.Emotes.Twitch.Enable,
-			"7tv":    &m.config.Emotes.SevenTv.Enable,
-			"bttv":   &m.config.Emotes.Bttv.Enable,
-			"ffz":    &m.config.Emotes.Ffz.Enable,
+			"twitch": &cfg.Emotes.Twitch.Enable,
+			"7tv":    &cfg.Emotes.SevenTv.Enable,
+			"bttv":   &cfg.Emotes.Bttv.Enable,
+			"ffz":    &cfg.Emotes.Ffz.Enable,
 		}
+		*emotesConfig[provider] = enable
+	})
+	m.handleScroll(formatSystemMessage(fmt.Sprintf("Emotes %s %sd", provider, state)))
+	return nil, nil
+}
 
-		enableValue, ok := emotesConfig[strings.ToLower(args[1])]
-		if !ok {
-			return nil, fmt.Errorf("Unknown option: %s (use twitch, 7tv, bttv or ffz)", args[1])
-		}
+func completeConfigKeys(m *Model, args []string) []string {
+	return config.Keys()
+}
 
-		switch strings.ToLower(args[2]) {
-		case "enable":
-			*enableValue = true
-			if m.twitch.ChannelID != "" {
-				emoteType := strings.ToLower(args[1])
-				initEmoteCache(emoteType, m.twitch.ChannelID, func(msg string) {
-					m.handleScroll(formatSystemMessage(msg))
-				})
-			}
-		case "disable":
-			*enableValue = false
-		default:
-			return nil, fmt.Errorf("Unknown option: %s (use enable or disable)", args[2])
+func completeSettableKeys(m *Model, args []string) []string {
+	var keys []string
+	for _, key := range config.Keys() {
+		if !config.ReadOnly(key) {
+			keys = append(keys, key)
 		}
+	}
+	return keys
+}
 
-		m.twitch.UpdateConfig(m.config)
-		if err := config.UpdateConfig(m.config); err != nil {
-			m.handleScroll(formatSystemMessage(fmt.Sprintf("Failed to save config: %v", err)))
-		}
-		m.handleScroll(formatSystemMessage(fmt.Sprintf("Emotes %s %sd", args[1], args[2])))
+func completeConfigSections(m *Model, args []string) []string {
+	return config.Sections()
+}
 
-	default:
-		return nil, fmt.Errorf("Unknown config subcommand: %s", args[0])
+// true / false for switches, otherwise the current value to edit
+func completeConfigValue(m *Model, args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+	if config.KeyKind(args[0]) == "bool" {
+		return []string{"true", "false"}
 	}
+	value, err := m.config.Get(args[0])
+	if err != nil || strings.Contains(value, " ") {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
 
-	return nil, nil
+// channels we joined before and the one from the config
+func completeChannels(m *Model, args []string) []string {
+	channels := m.history.Channels()
+	for _, channel := range m.config.ProfileChannels() {
+		if channel = strings.TrimPrefix(channel, "#"); !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	if m.config.Twitch.Channel != "" && !slices.Contains(channels, m.config.Twitch.Channel) {
+		channels = append(channels, m.config.Twitch.Channel)
+	}
+	sort.Strings(channels)
+	return channels
 }
 
-// reinits the emote chaches when we change them with the command
-func initEmoteCache(emoteType string, channelID string, msgHandler func(string)) {
-	switch emoteType {
-	case "7tv":
-		go func() {
-			if err := emotes.Init7tvCache(channelID); err != nil {
-				msgHandler(fmt.Sprintf("7tv emote cache init: %v", err))
-			}
-		}()
-	case "bttv":
-		go func() {
-			if err := emotes.InitBttvCache(channelID); err != nil {
-				msgHandler(fmt.Sprintf("bttv emote cache init: %v", err))
-			}
-		}()
-	case "ffz":
-		go func() {
-			if err := emotes.InitFfzCache(channelID); err != nil {
-				msgHandler(fmt.Sprintf("ffz emote cache init: %v", err))
-			}
-		}()
+// command names without the : prefix
+func completeCommandNames(m *Model, args []string) []string {
+	names := commandNames()
+	for i, name := range names {
+		names[i] = strings.TrimPrefix(name, ":")
 	}
+	return names
 }
@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"twitch-tui/internal/extentions/emotes"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	completionRows = 6   // candidates shown at once in the popup
+	maxChatters    = 200 // recently seen chatters offered for @ completion
+)
+
+// state of the tab completion - every tab moves to the next candidate
+type completion struct {
+	candidates []string
+	index      int
+	start      int    // rune position where the completed word starts
+	rest       string // text after the cursor
+	value      string // input after the last apply - when it changed we start over
+}
+
+// complete the word under the cursor or cycle to the next candidate
+func (m *Model) handleTab(reverse bool) {
+	c := m.completion
+	if c == nil || c.value != m.textInput.Value() {
+		c = m.newCompletion()
+		if c == nil {
+			return
+		}
+		if reverse {
+			c.index = len(c.candidates) - 1
+		}
+		m.completion = c
+	} else if reverse {
+		c.index = (c.index - 1 + len(c.candidates)) % len(c.candidates)
+	} else {
+		c.index = (c.index + 1) % len(c.candidates)
+	}
+
+	m.applyCompletion()
+}
+
+// look at the word in front of the cursor and collect what it could become
+func (m *Model) newCompletion() *completion {
+	runes := []rune(m.textInput.Value())
+	pos := min(m.textInput.Position(), len(runes))
+	before := string(runes[:pos])
+
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+	fields := strings.Fields(before[:start])
+
+	candidates := m.completionCandidates(fields, word)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return &completion{
+		candidates: candidates,
+		start:      len([]rune(before[:start])),
+		rest:       string(runes[pos:]),
+	}
+}
+
+// candidates depend on where we are - command name, command argument, @user or emote
+func (m *Model) completionCandidates(fields []string, word string) []string {
+	isCommand := strings.HasPrefix(strings.TrimSpace(m.textInput.Value()), ":")
+
+	switch {
+	case isCommand && len(fields) == 0:
+		return filterPrefix(commandNames(), word)
+
+	case strings.HasPrefix(word, "@"):
+		chatters := m.recentChatters()
+		for i, name := range chatters {
+			chatters[i] = "@" + name
+		}
+		return filterPrefix(chatters, word)
+
+	case isCommand:
+		def, ok := commandRegistry[strings.ToLower(strings.TrimPrefix(fields[0], ":"))]
+		if !ok {
+			return nil
+		}
+		return filterPrefix(completeArgs(m, def, fields[1:]), word)
+
+	case word == "": // chat - do not offer every emote on an empty word
+		return nil
+
+	default:
+		return filterPrefix(append(emotes.Codes(m.config), m.recentChatters()...), word)
+	}
+}
+
+// put the current candidate into the input - a single match also gets a trailing space
+func (m *Model) applyCompletion() {
+	c := m.completion
+	candidate := c.candidates[c.index]
+	if len(c.candidates) == 1 {
+		candidate += " "
+	}
+
+	runes := []rune(m.textInput.Value())
+	value := string(runes[:min(c.start, len(runes))]) + candidate + c.rest
+	m.textInput.SetValue(value)
+	m.textInput.SetCursor(c.start + len([]rune(candidate)))
+	m.updateInputState()
+
+	c.value = m.textInput.Value()
+	if len(c.candidates) == 1 { // nothing to cycle - the next tab completes the next word
+		m.completion = nil
+	}
+}
+
+// popup lines with a window of the candidates around the selected one
+func (m Model) completionView() []string {
+	c := m.completion
+	if c == nil || len(c.candidates) < 2 {
+		return nil
+	}
+
+	rows := min(completionRows, len(c.candidates))
+	first := min(max(c.index-rows/2, 0), len(c.candidates)-rows)
+	counter := fmt.Sprintf("%d/%d", c.index+1, len(c.candidates))
+
+	width := lipgloss.Width(counter)
+	for _, candidate := range c.candidates[first : first+rows] {
+		width = max(width, lipgloss.Width(candidate))
+	}
+	width += 2
+
+	normal := lipgloss.NewStyle().
+		Background(lipgloss.Color(m.config.Theme.Base)).
+		Foreground(lipgloss.Color(m.config.Theme.Text)).
+		Width(width)
+	selected := normal.
+		Background(lipgloss.Color(m.config.Theme.Lavender)).
+		Foreground(lipgloss.Color(m.config.Theme.Base))
+	info := normal.Foreground(lipgloss.Color(m.config.Theme.Subtext1))
+
+	lines := make([]string, 0, rows+1)
+	for i := first; i < first+rows; i++ {
+		style := normal
+		if i == c.index {
+			style = selected
+		}
+		lines = append(lines, style.Render(" "+c.candidates[i]))
+	}
+	lines = append(lines, info.Render(" "+counter))
+
+	return lines
+}
+
+// draw the popup over the last lines of the chat - aligned with the completed word
+func (m Model) overlayCompletion(chat string) string {
+	popup := m.completionView()
+	if len(popup) == 0 {
+		return chat
+	}
+
+	width := lipgloss.Width(popup[0])
+	col := min(lipgloss.Width(m.textInput.Prompt)+m.completion.start, max(m.width-width, 0))
+
+	lines := strings.Split(chat, "\n")
+	for i, row := range popup {
+		idx := len(lines) - len(popup) + i
+		if idx < 0 {
+			continue
+		}
+		left := ansi.Truncate(lines[idx], col, "")
+		left += strings.Repeat(" ", max(col-ansi.StringWidth(left), 0))
+		lines[idx] = left + row + ansi.TruncateLeft(lines[idx], col+width, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// every command name and alias with the : prefix
+func commandNames() []string {
+	names := make([]string, 0, len(commandRegistry))
+	for name := range commandRegistry {
+		names = append(names, ":"+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// users who wrote in the chat - newest first
+func (m Model) recentChatters() []string {
+	seen := make(map[string]bool)
+	var chatters []string
+	for i := m.messages.Len() - 1; i >= 0 && len(chatters) < maxChatters; i-- {
+		msg := m.messages.At(i).msg
+		if msg.Flare == "SYSTEM" || msg.User == "" || seen[msg.User] {
+			continue
+		}
+		seen[msg.User] = true
+		chatters = append(chatters, msg.User)
+	}
+	return chatters
+}
+
+// candidates starting with the word (case insensitive) - keeps the order and drops duplicates
+func filterPrefix(candidates []string, word string) []string {
+	word = strings.ToLower(word)
+	seen := make(map[string]bool)
+	var matches []string
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), word) {
+			continue
+		}
+		seen[candidate] = true
+		matches = append(matches, candidate)
+	}
+	return matches
+}
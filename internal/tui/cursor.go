@@ -0,0 +1,187 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// column in front of every chat line - shows the cursor and the selection
+const gutterWidth = 1
+
+// handle the vim keys of the message cursor in view mode - returns false when the key is not ours
+func (m *Model) handleCursorKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	pending := m.pendingKey
+	m.pendingKey = ""
+
+	switch msg.String() {
+	case "j":
+		m.moveCursor(1)
+	case "k":
+		m.moveCursor(-1)
+	case "g": // gg - wait for the second g
+		if pending != "g" {
+			m.pendingKey = "g"
+			return nil, true
+		}
+		m.setCursor(m.nextMatching(-1, 1))
+	case "G":
+		m.setCursor(m.nextMatching(m.messages.Len(), -1))
+		m.scroll = 0
+		m.refreshViewport()
+	case "v": // toggle visual selection starting at the cursor
+		if m.visual != 0 {
+			m.visual = 0
+		} else {
+			if m.cursorEntry() == nil {
+				m.moveCursor(0)
+			}
+			m.visual = m.cursor
+		}
+		m.refreshViewport()
+	case "y":
+		m.yankSelection()
+	case "r":
+		m.replyToCursor()
+	case "c":
+		m.showUserCard()
+	case "o":
+		m.openCursorLink()
+	case "t":
+		m.timeoutCursorUser()
+	case "esc": // drop cursor and selection - the chat follows again
+		if m.cursor == 0 && m.visual == 0 {
+			return nil, false
+		}
+		m.clearCursor()
+	default:
+		return nil, false
+	}
+
+	return nil, true
+}
+
+// the message under the cursor - nil when there is none or it already left the buffer
+func (m Model) cursorEntry() *chatEntry {
+	idx := m.messages.Index(m.cursor)
+	if idx < 0 {
+		return nil
+	}
+	return m.messages.At(idx)
+}
+
+// move the cursor by delta messages - the first move only places it on the newest visible message
+func (m *Model) moveCursor(delta int) {
+	idx := m.messages.Index(m.cursor)
+	if idx < 0 {
+		m.setCursor(m.bottomVisibleIndex())
+		return
+	}
+
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for range delta * step {
+		next := m.nextMatching(idx, step)
+		if next < 0 {
+			break
+		}
+		idx = next
+	}
+	m.setCursor(idx)
+}
+
+// put the cursor on the message at idx and scroll it into view
+func (m *Model) setCursor(idx int) {
+	if idx < 0 || idx >= m.messages.Len() {
+		return
+	}
+	m.cursor = m.messages.At(idx).seq
+	m.ensureCursorVisible()
+	m.refreshViewport()
+}
+
+func (m *Model) clearCursor() {
+	m.cursor = 0
+	m.visual = 0
+	m.scroll = 0
+	m.refreshViewport()
+}
+
+// next message after idx (in direction step) that passes the filter - -1 when there is none
+func (m Model) nextMatching(idx, step int) int {
+	for i := idx + step; i >= 0 && i < m.messages.Len(); i += step {
+		if m.matchesFilter(m.messages.At(i).msg) {
+			return i
+		}
+	}
+	return -1
+}
+
+// the newest message that is (at least partly) on screen
+func (m Model) bottomVisibleIndex() int {
+	lines := 0
+	last := -1
+	for i := m.messages.Len() - 1; i >= 0; i-- {
+		entry := m.messages.At(i)
+		if !m.matchesFilter(entry.msg) {
+			continue
+		}
+		last = i
+		lines += len(m.renderEntry(entry))
+		if lines > m.scroll {
+			return i
+		}
+	}
+	return last
+}
+
+// adjust the scroll so every line of the cursor message is on screen
+func (m *Model) ensureCursorVisible() {
+	idx := m.messages.Index(m.cursor)
+	if idx < 0 {
+		return
+	}
+
+	below := 0 // lines of the newer messages under the cursor
+	for i := m.messages.Len() - 1; i > idx; i-- {
+		if entry := m.messages.At(i); m.matchesFilter(entry.msg) {
+			below += len(m.renderEntry(entry))
+		}
+	}
+	height := len(m.renderEntry(m.messages.At(idx)))
+
+	switch {
+	case below < m.scroll:
+		m.scroll = below
+	case below+height > m.scroll+m.viewport.Height:
+		m.scroll = below + height - m.viewport.Height
+	}
+}
+
+// seq range of the selected messages - just the cursor when not in visual mode
+func (m Model) selection() (uint64, uint64) {
+	if m.cursorEntry() == nil {
+		return 0, 0
+	}
+	if m.visual == 0 {
+		return m.cursor, m.cursor
+	}
+
+	anchor := m.visual
+	if m.messages.Index(anchor) < 0 { // anchor left the buffer - select from the oldest message
+		anchor = m.messages.At(0).seq
+	}
+	return min(anchor, m.cursor), max(anchor, m.cursor)
+}
+
+// the gutter of a chat line - marks the cursor and the selected messages
+func (m Model) gutter(seq uint64, from, to uint64, styles ThemeStyles) string {
+	switch {
+	case seq == m.cursor:
+		return styles.Lavender.Render("▌")
+	case seq >= from && seq <= to:
+		return styles.Mauve.Render("▌")
+	default:
+		return " "
+	}
+}
@@ -19,13 +19,25 @@ func (m Model) footerView() string {
 		inputLabel = "Channel"
 	case stateView:
 		inputLabel = "View"
+		if m.visual != 0 {
+			inputLabel = "Visual"
+		}
+		if m.redemptions != nil {
+			inputLabel = "Redemptions"
+		}
 	case stateInputChat:
 		inputLabel = "Chat"
+		if m.replyTo != nil {
+			inputLabel = "Reply @" + m.replyTo.User
+		}
 	case stateInputCommand:
 		inputLabel = "Command"
 	default:
 		inputLabel = "Input"
 	}
+	if m.search != nil {
+		inputLabel = "Search"
+	}
 
 	chatPart := bracket + " " + styles.Maroon.Render(inputLabel) + " " + closeBracket
 	countPart := bracket + " " + styles.Yellow.Render(fmt.Sprintf("%d", len(m.textInput.Value()))) + styles.Maroon.Render(" / ") + styles.Yellow.Render("500") + " " + closeBracket
@@ -40,6 +52,9 @@ func (m Model) footerView() string {
 	infoLineWithSeparator := infoLine + separator
 
 	inputLine := m.textInput.View()
+	if m.search != nil {
+		inputLine = m.historySearchView()
+	}
 
 	return infoLineWithSeparator + "\n" + inputLine
 }
@@ -5,7 +5,10 @@ import (
 	"strings"
 	"time"
 
+	"twitch-tui/internal/twitch"
+
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func (m Model) headerView() string {
@@ -38,10 +41,50 @@ func (m Model) headerView() string {
 	dash := styles.Maroon.Render("─")
 	dataLine := timePart + dash + channelPart + dash + userPart + dash + findPart
 
+	// active chat modes - slow, sub-only, shield, ...
+	if modes := m.chatModes(); len(modes) > 0 {
+		dataLine += dash + bracket + styles.Maroon.Render(" Mode: ") + styles.Peach.Render(strings.Join(modes, " ")) + styles.Maroon.Render(" ") + closeBracket
+	}
+
+	dataLine = ansi.Truncate(dataLine, m.width, "")
 	dataLineWidth := lipgloss.Width(dataLine)
 
 	remainingSpace := max(m.width-dataLineWidth, 0)
 	separator := styles.Maroon.Render(strings.Repeat("─", remainingSpace))
 
-	return dataLine + separator + "\n"
+	return dataLine + separator + "\n" + m.streamLine()
+}
+
+// live / offline, uptime, viewers, category and title - empty until the first poll answered
+func (m Model) streamLine() string {
+	status := m.twitch.StreamStatus()
+	if !status.Known {
+		return ""
+	}
+
+	styles := m.getStyles()
+	bracket := styles.Maroon.Render("[")
+	closeBracket := styles.Maroon.Render("]")
+	dash := styles.Maroon.Render("─")
+	part := func(style lipgloss.Style, text string) string {
+		return bracket + " " + style.Render(text) + " " + closeBracket
+	}
+
+	stale := ""
+	if status.Stale {
+		stale = dash + part(styles.Subtext1, "stale")
+	}
+
+	if !status.Live {
+		return part(styles.Subtext1, "Offline") + stale
+	}
+
+	line := part(styles.Red, "● LIVE "+twitch.FormatUptime(time.Since(status.StartedAt))) +
+		dash + part(styles.Yellow, fmt.Sprintf("%d viewers", status.Viewers))
+	if status.Category != "" {
+		line += dash + part(styles.Teal, status.Category)
+	}
+	line += stale + dash + part(styles.Text, status.Title)
+
+	return ansi.Truncate(line, m.width, "…")
 }
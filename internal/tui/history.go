@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"strings"
+
+	"twitch-tui/internal/history"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reverse incremental search over the input history (ctrl+r)
+type historySearch struct {
+	kind     history.Kind
+	query    string
+	match    int    // index of the current match - -1 when nothing matches
+	failed   bool   // no older entry matches the query
+	original string // input before the search started
+}
+
+// which history the current input belongs to
+func (m Model) historyKind() history.Kind {
+	if m.state == stateInputCommand {
+		return history.Command
+	}
+	return history.Chat
+}
+
+// remember sent chat lines and commands for the current channel
+func (m *Model) rememberInput(input string) {
+	kind := history.Chat
+	if strings.HasPrefix(input, ":") {
+		kind = history.Command
+	}
+
+	m.historyPos = -1
+	if err := m.history.Add(m.twitch.CurrentChannel, kind, input); err != nil {
+		m.handleScroll(formatSystemMessage("Failed to save history: " + err.Error()))
+	}
+}
+
+// step through the history with up / down - after the newest entry the typed text comes back
+func (m *Model) recallHistory(delta int) {
+	if m.historyPos < 0 { // start navigating with the history of the current input kind
+		m.historyNav = m.historyKind()
+		m.historyDraft = m.textInput.Value()
+	}
+
+	entries := m.history.Entries(m.twitch.CurrentChannel, m.historyNav)
+	if len(entries) == 0 {
+		return
+	}
+	if m.historyPos < 0 || m.historyPos > len(entries) {
+		m.historyPos = len(entries)
+	}
+
+	m.historyPos = min(max(m.historyPos+delta, 0), len(entries))
+	if m.historyPos == len(entries) {
+		m.textInput.SetValue(m.historyDraft)
+	} else {
+		m.textInput.SetValue(entries[m.historyPos])
+	}
+	m.textInput.CursorEnd()
+	m.updateInputState()
+}
+
+func (m *Model) startHistorySearch() {
+	m.search = &historySearch{
+		kind:     m.historyKind(),
+		match:    -1,
+		original: m.textInput.Value(),
+	}
+}
+
+// close the search and restore what was typed before it
+func (m *Model) cancelHistorySearch() {
+	m.textInput.SetValue(m.search.original)
+	m.textInput.CursorEnd()
+	m.search = nil
+}
+
+// keys while the ctrl+r search is open - typing refines, ctrl+r goes further back
+func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	search := m.search
+	entries := m.history.Entries(m.twitch.CurrentChannel, search.kind)
+
+	switch msg.Type {
+	case tea.KeyCtrlR:
+		if search.match < 0 {
+			search.match = len(entries)
+		}
+		if next := findHistoryMatch(entries, search.query, search.match-1); next >= 0 {
+			search.match = next
+			search.failed = false
+		} else {
+			search.failed = true
+		}
+
+	case tea.KeyBackspace:
+		if runes := []rune(search.query); len(runes) > 0 {
+			search.query = string(runes[:len(runes)-1])
+		}
+		search.match = findHistoryMatch(entries, search.query, len(entries)-1)
+		search.failed = search.match < 0
+
+	case tea.KeyEsc, tea.KeyCtrlG: // give up - restore what was typed before
+		m.cancelHistorySearch()
+
+	case tea.KeyEnter, tea.KeyTab, tea.KeyRight: // take the match into the input
+		m.search = nil
+		if search.match >= 0 && search.match < len(entries) {
+			m.textInput.SetValue(entries[search.match])
+		} else {
+			m.textInput.SetValue(search.original)
+		}
+		m.textInput.CursorEnd()
+		m.historyPos = -1
+		m.updateInputState()
+
+	case tea.KeyRunes, tea.KeySpace:
+		search.query += string(msg.Runes)
+		from := search.match
+		if from < 0 {
+			from = len(entries) - 1
+		}
+		search.match = findHistoryMatch(entries, search.query, from)
+		search.failed = search.match < 0
+	}
+
+	return m, nil
+}
+
+// the search line shown instead of the input
+func (m Model) historySearchView() string {
+	styles := m.getStyles()
+	search := m.search
+
+	label := "(reverse-i-search)"
+	if search.failed {
+		label = "(failing reverse-i-search)"
+	}
+
+	match := ""
+	if entries := m.history.Entries(m.twitch.CurrentChannel, search.kind); search.match >= 0 && search.match < len(entries) {
+		match = entries[search.match]
+	}
+
+	return styles.Subtext1.Render(label) + styles.Yellow.Render("'"+search.query+"'") + styles.Subtext1.Render(": ") + styles.Text.Render(match)
+}
+
+// newest entry at or before from that contains the query
+func findHistoryMatch(entries []string, query string, from int) int {
+	query = strings.ToLower(query)
+	for i := min(from, len(entries)-1); i >= 0; i-- {
+		if strings.Contains(strings.ToLower(entries[i]), query) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHistorySearchLetsQuitThrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      tea.KeyType
+		wantQuit bool
+	}{
+		{"ctrl+q quits", tea.KeyCtrlQ, true},
+		{"ctrl+c leaves the search", tea.KeyCtrlC, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Model{textInput: textinput.New()}
+			m.search = &historySearch{match: -1, original: "typed", query: "sa"}
+			m.textInput.SetValue("found")
+
+			_, cmd := m.handleKey(tea.KeyMsg{Type: tt.key})
+			if m.search != nil {
+				t.Error("search still open")
+			}
+			if got := m.textInput.Value(); got != "typed" {
+				t.Errorf("input = %q, want what was typed before the search", got)
+			}
+			quit := false
+			if cmd != nil {
+				_, quit = cmd().(tea.QuitMsg)
+			}
+			if quit != tt.wantQuit {
+				t.Errorf("quit = %v, want %v", quit, tt.wantQuit)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"twitch-tui/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const maxMacroDepth = 8 // macros calling macros - stops endless loops
+
+var (
+	macroParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*|[0-9]+|\*)(?::([^}]*))?\}`)
+	namedArg   = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+)
+
+// names of the commands that came from the config - replaced on every reload
+var userCommands []string
+
+// register the aliases and macros of the config as commands - returns the ones we had to skip
+func registerUserCommands(cfg config.Config) []string {
+	for _, name := range userCommands {
+		delete(commandRegistry, name)
+		commandOrder = slices.DeleteFunc(commandOrder, func(n string) bool { return n == name })
+	}
+	userCommands = nil
+
+	var warnings []string
+	add := func(name, description string, steps []string) {
+		name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), ":"))
+		switch {
+		case name == "" || strings.ContainsAny(name, " \t"):
+			warnings = append(warnings, fmt.Sprintf("invalid command name %q", name))
+			return
+		case len(steps) == 0:
+			warnings = append(warnings, fmt.Sprintf(":%s has no steps", name))
+			return
+		}
+		if _, taken := commandRegistry[name]; taken {
+			warnings = append(warnings, fmt.Sprintf(":%s already exists - skipped", name))
+			return
+		}
+
+		registerCommand(macroCommand(name, description, steps))
+		userCommands = append(userCommands, name)
+	}
+
+	// sorted so conflicts between the two tables always resolve the same way
+	for _, name := range sortedKeys(cfg.Aliases) {
+		template := cfg.Aliases[name]
+		add(name, "alias for "+template, splitSteps(template))
+	}
+	for _, name := range sortedKeys(cfg.Macros) {
+		macro := cfg.Macros[name]
+		var steps []string
+		for _, step := range macro.Steps {
+			steps = append(steps, splitSteps(step)...)
+		}
+		description := macro.Description
+		if description == "" {
+			description = "macro: " + strings.Join(steps, "; ")
+		}
+		add(name, description, steps)
+	}
+
+	return warnings
+}
+
+// command that expands its steps with the given args and runs them one after another
+func macroCommand(name, description string, steps []string) commandDef {
+	return commandDef{
+		Name:        name,
+		Description: description,
+		Args:        []argSpec{{Name: "args", Help: "positional values and name=value pairs", Optional: true, Rest: true}},
+		Handle: func(m *Model, args []string) (tea.Cmd, error) {
+			return m.runMacro(steps, args)
+		},
+	}
+}
+
+// expand every step - commands run right away, chat lines are sent in order
+func (m *Model) runMacro(steps []string, args []string) (tea.Cmd, error) {
+	if m.macroDepth >= maxMacroDepth {
+		return nil, errors.New("macros nested too deep")
+	}
+
+	positional, named := splitMacroArgs(steps, args)
+	named["channel"] = fallback(named["channel"], m.twitch.CurrentChannel)
+	named["user"] = fallback(named["user"], m.config.Twitch.User)
+
+	// expand all steps first so a missing parameter stops the macro before anything ran
+	expanded := make([]string, 0, len(steps))
+	for _, step := range steps {
+		line, err := expandMacro(step, positional, named)
+		if err != nil {
+			return nil, err
+		}
+		if line != "" {
+			expanded = append(expanded, line)
+		}
+	}
+
+	m.macroDepth++
+	defer func() { m.macroDepth-- }()
+
+	var cmds []tea.Cmd
+	for _, line := range expanded {
+		if strings.HasPrefix(line, ":") {
+			cmds = append(cmds, m.executeCommand(line))
+			continue
+		}
+		cmds = append(cmds, m.sendMsgCmd(line))
+	}
+
+	return tea.Sequence(cmds...), nil
+}
+
+// fill the placeholders of a step
+func expandMacro(step string, positional []string, named map[string]string) (string, error) {
+	var missing error
+	line := macroParam.ReplaceAllStringFunc(step, func(match string) string {
+		parts := macroParam.FindStringSubmatch(match)
+		key, def := parts[1], parts[2]
+		hasDefault := strings.Contains(match, ":")
+
+		var value string
+		switch {
+		case key == "*":
+			value = strings.Join(positional, " ")
+		case isDigits(key):
+			if idx, _ := strconv.Atoi(key); idx >= 1 && idx <= len(positional) {
+				value = positional[idx-1]
+			}
+		default:
+			value = named[key]
+		}
+
+		if value == "" && hasDefault {
+			value = def
+		}
+		if value == "" && key != "*" && !hasDefault && missing == nil {
+			missing = fmt.Errorf("missing parameter {%s}", key)
+		}
+		return value
+	})
+
+	return strings.TrimSpace(line), missing
+}
+
+// name=value args are only named when a step uses that name - everything else is positional
+func splitMacroArgs(steps []string, args []string) ([]string, map[string]string) {
+	used := make(map[string]bool)
+	for _, step := range steps {
+		for _, parts := range macroParam.FindAllStringSubmatch(step, -1) {
+			used[parts[1]] = true
+		}
+	}
+
+	named := make(map[string]string)
+	var positional []string
+	for _, arg := range args {
+		if parts := namedArg.FindStringSubmatch(arg); parts != nil && used[parts[1]] {
+			named[parts[1]] = parts[2]
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return positional, named
+}
+
+// split a template on ; - \; keeps a literal semicolon
+func splitSteps(template string) []string {
+	var steps []string
+	var current strings.Builder
+	runes := []rune(template)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == ';':
+			current.WriteRune(';')
+			i++
+		case runes[i] == ';':
+			steps = append(steps, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(runes[i])
+		}
+	}
+	steps = append(steps, current.String())
+
+	return slices.DeleteFunc(steps, func(step string) bool {
+		return strings.TrimSpace(step) == ""
+	})
+}
+
+// sends the text as a chat message - handy in macros for messages starting with ! or /
+func handleSayCommand(m *Model, args []string) (tea.Cmd, error) {
+	return m.sendMsgCmd(strings.Join(args, " ")), nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func fallback(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
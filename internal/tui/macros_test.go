@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSplitSteps(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{":join {1}", []string{":join {1}"}},
+		{":join {1};:say hi", []string{":join {1}", ":say hi"}},
+		{":say a\\; b", []string{":say a; b"}},
+		{":say a\\;;:say b", []string{":say a;", ":say b"}},
+		{";; :say a ;  ;", []string{" :say a "}},
+		{"", nil},
+		{"\\", []string{"\\"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := splitSteps(tt.template); !slices.Equal(got, tt.want) {
+				t.Errorf("splitSteps(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandMacro(t *testing.T) {
+	tests := []struct {
+		name       string
+		step       string
+		positional []string
+		named      map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{"positional", ":timeout {1} {2}", []string{"spammer", "10m"}, nil, ":timeout spammer 10m", false},
+		{"all args", ":say {*}", []string{"hello", "there"}, nil, ":say hello there", false},
+		{"no args for all", ":say hi {*}", nil, nil, ":say hi", false},
+		{"named", ":join {channel}", nil, map[string]string{"channel": "foo"}, ":join foo", false},
+		{"default used", ":timeout {1} {2:600}", []string{"spammer"}, nil, ":timeout spammer 600", false},
+		{"default ignored", ":timeout {1} {2:600}", []string{"spammer", "60"}, nil, ":timeout spammer 60", false},
+		{"empty default", ":say {1:}", nil, nil, ":say", false},
+		{"named default", ":join {channel:bar}", nil, nil, ":join bar", false},
+		{"missing positional", ":timeout {1} {2}", []string{"spammer"}, nil, ":timeout spammer", true},
+		{"missing named", ":join {channel}", nil, nil, ":join", true},
+		{"index zero", ":say {0}", []string{"a"}, nil, ":say", true},
+		{"no placeholders", ":quit", []string{"a"}, nil, ":quit", false},
+		{"not a placeholder", ":say {a-b}", nil, nil, ":say {a-b}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandMacro(tt.step, tt.positional, tt.named)
+			if got != tt.want {
+				t.Errorf("expandMacro(%q) = %q, want %q", tt.step, got, tt.want)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expandMacro(%q) error = %v, want error %v", tt.step, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitMacroArgs(t *testing.T) {
+	steps := []string{":join {channel}", ":say {1} {*}"}
+	tests := []struct {
+		name           string
+		args           []string
+		wantPositional []string
+		wantNamed      map[string]string
+	}{
+		{"only positional", []string{"a", "b"}, []string{"a", "b"}, map[string]string{}},
+		{"used name", []string{"channel=foo", "a"}, []string{"a"}, map[string]string{"channel": "foo"}},
+		{"unused name stays positional", []string{"reason=spam"}, []string{"reason=spam"}, map[string]string{}},
+		{"value with =", []string{"channel=a=b"}, nil, map[string]string{"channel": "a=b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positional, named := splitMacroArgs(steps, tt.args)
+			if !slices.Equal(positional, tt.wantPositional) {
+				t.Errorf("positional = %q, want %q", positional, tt.wantPositional)
+			}
+			if !maps.Equal(named, tt.wantNamed) {
+				t.Errorf("named = %v, want %v", named, tt.wantNamed)
+			}
+		})
+	}
+}
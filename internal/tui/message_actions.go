@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"twitch-tui/internal/twitch"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s]+`)
+
+// copy the selected messages as plain text to the clipboard
+func (m *Model) yankSelection() {
+	from, to := m.selection()
+	if from == 0 {
+		m.handleScroll(formatSystemMessage("Nothing to yank - move the cursor with j/k first"))
+		return
+	}
+
+	var lines []string
+	for i := m.messages.Index(from); i <= m.messages.Index(to); i++ {
+		if entry := m.messages.At(i); m.matchesFilter(entry.msg) {
+			lines = append(lines, m.plainMessage(entry.msg))
+		}
+	}
+
+	m.visual = 0
+	if err := copyToClipboard(strings.Join(lines, "\n")); err != nil {
+		m.handleScroll(formatSystemMessage("Yank failed: " + err.Error()))
+		return
+	}
+	m.handleScroll(formatSystemMessage(fmt.Sprintf("Yanked %d message(s)", len(lines))))
+}
+
+// start typing a reply to the message under the cursor
+func (m *Model) replyToCursor() {
+	entry := m.cursorEntry()
+	if entry == nil || entry.msg.ID == "" {
+		m.handleScroll(formatSystemMessage("Nothing to reply to - move the cursor to a chat message"))
+		return
+	}
+
+	parent := entry.msg
+	m.replyTo = &parent
+	m.state = stateInputChat
+	m.textInput.Focus()
+	m.textInput.SetValue("")
+}
+
+// prefill a timeout for the author of the message under the cursor
+func (m *Model) timeoutCursorUser() {
+	entry := m.cursorEntry()
+	if entry == nil || entry.msg.ID == "" {
+		m.handleScroll(formatSystemMessage("Nobody to time out - move the cursor to a chat message"))
+		return
+	}
+
+	m.setCommand(":timeout " + entry.msg.User + " ")
+}
+
+// print what we know about the author of the message under the cursor
+func (m *Model) showUserCard() {
+	entry := m.cursorEntry()
+	if entry == nil {
+		m.handleScroll(formatSystemMessage("No message under the cursor"))
+		return
+	}
+
+	user := entry.msg.User
+	var first, last twitch.ChatMessage
+	count := 0
+	for i := range m.messages.Len() {
+		msg := m.messages.At(i).msg
+		if msg.User != user {
+			continue
+		}
+		if count == 0 {
+			first = msg
+		}
+		last = msg
+		count++
+	}
+
+	card := fmt.Sprintf("User: %s", user)
+	if entry.msg.UserID != "" {
+		card += fmt.Sprintf(" (id %s)", entry.msg.UserID)
+	}
+	if entry.msg.Flare != "" {
+		card += fmt.Sprintf("\nFlare: %s", entry.msg.Flare)
+	}
+	if len(entry.msg.Badges) > 0 {
+		card += "\nBadges: " + describeBadges(entry.msg.Badges)
+	}
+	card += fmt.Sprintf("\nMessages in scrollback: %d", count)
+	card += fmt.Sprintf("\nFirst seen: %s - last seen: %s",
+		first.Time.Format(m.config.Style.DateFormat), last.Time.Format(m.config.Style.DateFormat))
+	m.handleScroll(formatSystemMessage(card))
+}
+
+// subscriber/3024 (26 months), moderator/1
+func describeBadges(badges []twitch.Badge) string {
+	names := make([]string, len(badges))
+	for i, badge := range badges {
+		names[i] = badge.String()
+		if badge.Months > 0 {
+			names[i] += fmt.Sprintf(" (%d months)", badge.Months)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// open the first link of the message under the cursor in the browser
+func (m *Model) openCursorLink() {
+	entry := m.cursorEntry()
+	if entry == nil {
+		m.handleScroll(formatSystemMessage("No message under the cursor"))
+		return
+	}
+
+	link := linkPattern.FindString(ansi.Strip(entry.msg.Content))
+	if link == "" {
+		m.handleScroll(formatSystemMessage("No link in this message"))
+		return
+	}
+
+	if err := openURL(link); err != nil {
+		m.handleScroll(formatSystemMessage("Open link failed: " + err.Error()))
+		return
+	}
+	m.handleScroll(formatSystemMessage("Opened " + link))
+}
+
+// message as plain text without any colors or hyperlinks
+func (m Model) plainMessage(msg twitch.ChatMessage) string {
+	content := ansi.Strip(msg.Content)
+	if msg.Prepend != "" {
+		content = msg.Prepend + " " + content
+	}
+	return fmt.Sprintf("%s %s: %s", msg.Time.Format(m.config.Style.DateFormat), msg.User, content)
+}
+
+// write the text to the system clipboard with an OSC 52 escape sequence - works over ssh too
+// goes to stderr so it does not get mixed into the frames bubbletea writes to stdout
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
+
+// open a link with the default browser of the os
+func openURL(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait() // reap the process once the browser took over
+	return nil
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"twitch-tui/internal/config"
+	"twitch-tui/internal/twitch"
+)
+
+const minScrollback = 100
+
+// everything the rendered lines of a message depend on - when one of them changes the cache is stale
+type renderKey struct {
+	width  int
+	theme  config.Theme
+	style  config.Style
+	badges string // the [badges] section - see badgeKey
+}
+
+// a buffered chat message with its rendered lines
+type chatEntry struct {
+	seq   uint64 // stable id of the entry - never reused
+	msg   twitch.ChatMessage
+	key   renderKey
+	lines []string
+}
+
+// fixed size ring buffer of chat messages - when full the oldest message gets dropped
+type messageBuffer struct {
+	entries []*chatEntry
+	start   int // index of the oldest entry
+	size    int
+	nextSeq uint64
+}
+
+func newMessageBuffer(capacity int) *messageBuffer {
+	capacity = max(capacity, minScrollback)
+	return &messageBuffer{entries: make([]*chatEntry, capacity)}
+}
+
+// add a message - overwrites the oldest one when the buffer is full
+func (b *messageBuffer) Push(msg twitch.ChatMessage) *chatEntry {
+	b.nextSeq++
+	entry := &chatEntry{seq: b.nextSeq, msg: msg}
+	if b.size < len(b.entries) {
+		b.entries[(b.start+b.size)%len(b.entries)] = entry
+		b.size++
+		return entry
+	}
+
+	b.entries[b.start] = entry
+	b.start = (b.start + 1) % len(b.entries)
+	return entry
+}
+
+// number of buffered messages
+func (b *messageBuffer) Len() int {
+	return b.size
+}
+
+// get the message at i - 0 is the oldest
+func (b *messageBuffer) At(i int) *chatEntry {
+	return b.entries[(b.start+i)%len(b.entries)]
+}
+
+// get the position of an entry by its seq - -1 when it was dropped already
+// seqs of the buffered entries are always contiguous so this is just an offset
+func (b *messageBuffer) Index(seq uint64) int {
+	if b.size == 0 || seq == 0 {
+		return -1
+	}
+
+	oldest := b.At(0).seq
+	if seq < oldest || seq > b.nextSeq {
+		return -1
+	}
+	return int(seq - oldest)
+}
+
+// drop all messages
+func (b *messageBuffer) Reset() {
+	clear(b.entries)
+	b.start = 0
+	b.size = 0
+}
+
+// change the capacity - keeps the newest messages that still fit
+func (b *messageBuffer) Resize(capacity int) {
+	capacity = max(capacity, minScrollback)
+	if capacity == len(b.entries) {
+		return
+	}
+
+	keep := min(b.size, capacity)
+	entries := make([]*chatEntry, capacity)
+	for i := range keep {
+		entries[i] = b.At(b.size - keep + i)
+	}
+
+	b.entries = entries
+	b.start = 0
+	b.size = keep
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"twitch-tui/internal/twitch"
+)
+
+// contents of the buffer oldest first
+func bufferContents(b *messageBuffer) []string {
+	var contents []string
+	for i := range b.Len() {
+		contents = append(contents, b.At(i).msg.Content)
+	}
+	return contents
+}
+
+func pushN(b *messageBuffer, from, to int) {
+	for i := from; i <= to; i++ {
+		b.Push(twitch.ChatMessage{Content: fmt.Sprint(i)})
+	}
+}
+
+func numbers(from, to int) []string {
+	var out []string
+	for i := from; i <= to; i++ {
+		out = append(out, fmt.Sprint(i))
+	}
+	return out
+}
+
+func TestMessageBufferPush(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		pushed   int
+		want     []string
+	}{
+		{"empty", minScrollback, 0, nil},
+		{"below capacity", minScrollback, 3, numbers(1, 3)},
+		{"exactly full", minScrollback, minScrollback, numbers(1, minScrollback)},
+		{"wraps around", minScrollback, minScrollback + 5, numbers(6, minScrollback+5)},
+		{"small capacity is raised", 1, minScrollback + 1, numbers(2, minScrollback+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMessageBuffer(tt.capacity)
+			pushN(b, 1, tt.pushed)
+			if got := bufferContents(b); !slices.Equal(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageBufferIndex(t *testing.T) {
+	b := newMessageBuffer(minScrollback)
+	var entries []*chatEntry
+	for i := range minScrollback + 10 {
+		entries = append(entries, b.Push(twitch.ChatMessage{Content: fmt.Sprint(i)}))
+	}
+
+	tests := []struct {
+		name string
+		seq  uint64
+		want int
+	}{
+		{"zero seq", 0, -1},
+		{"dropped", entries[0].seq, -1},
+		{"last dropped", entries[9].seq, -1},
+		{"oldest kept", entries[10].seq, 0},
+		{"newest", entries[len(entries)-1].seq, minScrollback - 1},
+		{"not pushed yet", entries[len(entries)-1].seq + 1, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Index(tt.seq); got != tt.want {
+				t.Errorf("Index(%d) = %d, want %d", tt.seq, got, tt.want)
+			}
+			if tt.want >= 0 && b.At(tt.want).seq != tt.seq {
+				t.Errorf("At(%d).seq = %d, want %d", tt.want, b.At(tt.want).seq, tt.seq)
+			}
+		})
+	}
+}
+
+func TestMessageBufferResize(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     int // capacity before the resize
+		pushed   int
+		capacity int
+		want     []string
+	}{
+		{"grow keeps all", minScrollback, minScrollback + 20, 2 * minScrollback, numbers(21, minScrollback+20)},
+		{"shrink keeps the newest", 2 * minScrollback, 150, minScrollback, numbers(51, 150)},
+		{"shrink below the minimum", 2 * minScrollback, 150, 10, numbers(51, 150)},
+		{"same size", 2 * minScrollback, 30, 2 * minScrollback, numbers(1, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMessageBuffer(tt.from)
+			pushN(b, 1, tt.pushed)
+			b.Resize(tt.capacity)
+			if got := bufferContents(b); !slices.Equal(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+
+			// the buffer keeps working after the resize
+			b.Push(twitch.ChatMessage{Content: "next"})
+			if last := b.At(b.Len() - 1).msg.Content; last != "next" {
+				t.Errorf("newest after push = %q, want next", last)
+			}
+		})
+	}
+}
+
+func TestMessageBufferReset(t *testing.T) {
+	b := newMessageBuffer(minScrollback)
+	pushN(b, 1, 5)
+	last := b.Push(twitch.ChatMessage{Content: "6"})
+	b.Reset()
+
+	if b.Len() != 0 {
+		t.Fatalf("Len after Reset = %d, want 0", b.Len())
+	}
+	if got := b.Index(last.seq); got != -1 {
+		t.Errorf("Index of a dropped entry = %d, want -1", got)
+	}
+
+	next := b.Push(twitch.ChatMessage{Content: "7"})
+	if next.seq <= last.seq {
+		t.Errorf("seq after Reset = %d, want more than %d - seqs are never reused", next.seq, last.seq)
+	}
+	if got := b.Index(next.seq); got != 0 {
+		t.Errorf("Index of the first entry after Reset = %d, want 0", got)
+	}
+}
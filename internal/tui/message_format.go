@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -31,33 +32,41 @@ func (m Model) formatMessage(msg twitch.ChatMessage) string {
 		closeBracket := styles.Maroon.Render("]")
 		flareStyle := styles.Red
 		switch msg.Flare {
-		case "VIP":
-			flareStyle = styles.Pink
 		case "SYSTEM":
 			flareStyle = styles.Yellow
 		case "TUI":
 			flareStyle = styles.Blue
 		case "REDEEM":
 			flareStyle = styles.Teal
+		case "FOLLOW":
+			flareStyle = styles.Green
+		case "POLL", "PREDICT":
+			flareStyle = styles.Sky
+		case "HYPE":
+			flareStyle = styles.Peach
 		}
 		flarePart = bracket + flareStyle.Render(msg.Flare) + closeBracket + " "
 	}
+	flarePart += m.formatBadges(msg.Badges)
 
 	// handle the user string and color
 	var userStr string
 	if msg.Flare == "SYSTEM" {
 		userStr = styles.Yellow.Render(msg.User)
 	} else {
-		userStr = lipgloss.NewStyle().Foreground(lipgloss.Color(msg.NameColor)).Render(msg.User)
+		userStr = lipgloss.NewStyle().Foreground(m.nameColor(msg.NameColor)).Render(msg.User)
 	}
 
 	// combine the the @ users and their colors
 	contentPart := msg.Content
 	for _, taggedUser := range msg.TaggedUsers {
 		taggedPattern := "@" + taggedUser
-		taggedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(msg.TaggedColors[taggedUser]))
+		taggedStyle := lipgloss.NewStyle().Foreground(m.nameColor(msg.TaggedColors[taggedUser]))
 		contentPart = strings.ReplaceAll(contentPart, taggedPattern, taggedStyle.Render(taggedPattern))
 	}
+	if msg.Annotation != "" {
+		contentPart += " " + styles.Subtext1.Italic(true).Render("["+msg.Annotation+"]")
+	}
 
 	// add style to the pepend text ie. - Cheer100 -
 	prependPart := ""
@@ -67,7 +76,7 @@ func (m Model) formatMessage(msg twitch.ChatMessage) string {
 
 	// calcualte the avaiable space for the text
 	prefixLen := lipgloss.Width(timePart) + lipgloss.Width(flarePart) + lipgloss.Width(userStr) + lipgloss.Width(prependPart) + 2
-	availableWidth := max(m.width-prefixLen, 20)
+	availableWidth := max(m.width-gutterWidth-prefixLen-1, 20)
 	// split the text according to the avaiable width
 	wrappedContent := m.wrapText(contentPart, availableWidth)
 	lines := strings.Split(wrappedContent, "\n")
@@ -93,6 +102,35 @@ func (m Model) formatMessage(msg twitch.ChatMessage) string {
 	return result.String()
 }
 
+// the badges as glyphs in their colours - [BC S24] - at most badges.max of them
+func (m Model) formatBadges(badges []twitch.Badge) string {
+	if !m.config.Badges.Enable || len(badges) == 0 {
+		return ""
+	}
+	styles := m.getStyles()
+
+	var glyphs []string
+	hidden := 0
+	for _, badge := range badges {
+		text, color, hide := m.config.BadgeGlyph(badge.Name, badge.Version, badge.Months)
+		if hide || text == "" {
+			continue
+		}
+		if limit := m.config.Badges.Max; limit > 0 && len(glyphs) >= limit {
+			hidden++
+			continue
+		}
+		glyphs = append(glyphs, lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(text))
+	}
+	if hidden > 0 {
+		glyphs = append(glyphs, styles.Subtext1.Render(fmt.Sprintf("+%d", hidden)))
+	}
+	if len(glyphs) == 0 {
+		return ""
+	}
+	return styles.Maroon.Render("[") + strings.Join(glyphs, " ") + styles.Maroon.Render("]") + " "
+}
+
 // handle text warap on too long mesages
 func (m Model) wrapText(text string, width int) string {
 	if width <= 0 {
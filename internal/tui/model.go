@@ -1,9 +1,16 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 	"twitch-tui/internal/config"
+	"twitch-tui/internal/history"
+	"twitch-tui/internal/plugins"
 	"twitch-tui/internal/twitch"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -15,6 +22,15 @@ import (
 type appState int
 type systemMsg string
 type tickMsg struct{}
+type chatBatchMsg []twitch.ChatMessage
+type noticeMsg string // system message from inside the tui - unlike systemMsg it does not re-arm the SysChan listener
+type configMsg config.Config
+type configReloadMsg struct{ err error } // config.toml changed on disk - err when the edit was rejected
+
+const (
+	frameInterval = time.Second / 30 // max rate the chat gets rebuilt with
+	maxBatchSize  = 500
+)
 
 // theme converted from hex to lipgloss
 type ThemeStyles struct {
@@ -39,7 +55,7 @@ type ThemeStyles struct {
 
 const (
 	stateInputChannel appState = iota
-	stateView                  // Normal mode - hjkl navigation, i for insert, : for command
+	stateView                  // Normal mode - j/k message cursor, v select, y yank, i for insert, : for command
 	stateInputChat             // Insert mode - typing chat messages
 	stateInputCommand          // Command mode - typing commands
 )
@@ -47,17 +63,47 @@ const (
 type Model struct {
 	state     appState
 	twitch    *twitch.Service
-	config    config.Config
-	messages  []twitch.ChatMessage
+	store     *config.Store
+	config    config.Config // copy of the store - changes go through updateConfig
+	configSub <-chan config.Config
+	reloads   <-chan error       // results of the config.toml watcher
+	stopWatch context.CancelFunc // ends the watcher on Close
+	messages  *messageBuffer
+	scroll    int // lines scrolled up from the newest message - 0 follows the chat
 	viewport  viewport.Model
 	textInput textinput.Model
 	width     int
 	height    int
 	ready     bool
 	filter    string
+
+	cursor     uint64              // seq of the message under the cursor - 0 when there is none
+	visual     uint64              // seq where the visual selection started - 0 when not selecting
+	pendingKey string              // first key of a two key motion like gg
+	replyTo    *twitch.ChatMessage // message we are answering in insert mode
+
+	history      *history.Store
+	historyPos   int          // position while stepping through the history - -1 when not navigating
+	historyNav   history.Kind // history we are stepping through
+	historyDraft string       // what was typed before navigating
+	search       *historySearch
+
+	completion *completion
+
+	macroDepth int      // nesting of running macros
+	notices    []string // messages collected before the ui was up
+
+	plugins *plugins.Manager
+
+	redemptions *redemptionPanel // open :redemptions queue
+
+	nameColors map[nameColorKey]string // user colours after the contrast adjustment
+	badgeKey   string                  // [badges] as text for the render cache - the glyph map is not comparable
 }
 
-func New(cfg config.Config) Model {
+func New(store *config.Store) Model {
+	cfg := store.Get()
+
 	ti := textinput.New()
 	ti.Placeholder = "Enter channel"
 	ti.Focus()
@@ -70,23 +116,60 @@ func New(cfg config.Config) Model {
 		ti.Blur()
 	}
 
+	notices := config.Notices()
+	for _, warning := range registerUserCommands(cfg) {
+		notices = append(notices, "Config commands: "+warning)
+	}
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+
 	return Model{
-		state:     state,
-		config:    cfg,
-		textInput: ti,
-		twitch:    twitch.New(cfg),
+		notices:    notices,
+		state:      state,
+		store:      store,
+		config:     cfg,
+		configSub:  store.Subscribe(),
+		reloads:    store.Watch(watchCtx),
+		stopWatch:  stopWatch,
+		messages:   newMessageBuffer(cfg.Chat.Scrollback),
+		textInput:  ti,
+		twitch:     twitch.New(store),
+		history:    openHistory(cfg),
+		historyPos: -1,
+		plugins:    plugins.Start(cfg),
+		nameColors: map[nameColorKey]string{},
+		badgeKey:   fmt.Sprint(cfg.Badges),
 	}
 }
 
+// load the input history from the config directory - without one the history is not saved
+func openHistory(cfg config.Config) *history.Store {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil
+	}
+
+	store, _ := history.Open(filepath.Join(dir, history.FileName), cfg.Chat.HistorySize) // a broken file starts a fresh history
+	return store
+}
+
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		textinput.Blink,
 		waitForSystemMsg(m.twitch.SysChan), // listen to the system messages
+		waitForConfig(m.configSub),
+		waitForConfigReload(m.reloads),
+		waitForPluginAction(m.plugins.Actions),
+		waitForChatSettings(m.twitch.SettingsChan),                            // redraw the header when the chat modes change
 		tea.Tick(time.Second, func(_ time.Time) tea.Msg { return tickMsg{} }), // add tick messages - updating the time correctly
 	}
 
+	for _, notice := range m.notices {
+		cmds = append(cmds, func() tea.Msg { return noticeMsg(notice) })
+	}
+
 	if m.state == stateView {
-		cmds = append(cmds, m.connectCmd(), waitForChatMsg(m.twitch.MsgChan)) // listen to the twitch chat messages
+		cmds = append(cmds, m.connectCmd(), waitForChatBatch(m.twitch.MsgChan, m.plugins, time.Time{})) // listen to the twitch chat messages
 	}
 
 	return tea.Batch(cmds...)
@@ -99,28 +182,60 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case systemMsg: // print system message
 		m.handleScroll(formatSystemMessage(string(msg)))
+		m.plugins.Event("system", string(msg))
 		return m, waitForSystemMsg(m.twitch.SysChan)
 
-	case loginCompleteMsg:
+	case chatSettingsMsg:
+		return m, waitForChatSettings(m.twitch.SettingsChan)
+
+	case redemptionsLoadedMsg:
+		m.handleRedemptionsLoaded(msg)
+		return m, nil
+
+	case redemptionDoneMsg:
+		m.handleRedemptionDone(msg)
+		return m, nil
+
+	case pluginActionMsg:
+		return m, tea.Batch(m.handlePluginAction(plugins.Action(msg)), waitForPluginAction(m.plugins.Actions))
+
+	case pluginResultMsg:
+		return m, m.handlePluginResult(msg)
+
+	case noticeMsg:
+		m.handleScroll(formatSystemMessage(string(msg)))
+		return m, nil
+
+	case loginCompleteMsg: // the service saved the login - it reaches us as configMsg
 		if msg.Err != nil {
 			m.handleScroll(formatSystemMessage("login failed: " + msg.Err.Error()))
 			return m, nil
 		}
 
-		m.config.Twitch.User = msg.User
-		m.config.Twitch.Oauth = msg.OAuth
-		m.config.Twitch.Refresh = msg.Refresh
-		m.config.Twitch.ClientID = msg.ClientID
-		m.config.Twitch.UserID = msg.UserID
-		if err := config.UpdateConfig(m.config); err != nil {
-			m.handleScroll(formatSystemMessage("Failed to save config: " + err.Error()))
-		}
 		m.handleScroll(formatSystemMessage("Logged in as " + msg.User))
 		return m, nil
 
-	case twitch.ChatMessage: // print chat message
+	case configMsg: // someone changed the config - tokens refreshed, ids fetched, ...
+		if !reflect.DeepEqual(config.Config(msg), m.config) { // our own changes were applied by updateConfig already
+			m.applyConfig(config.Config(msg))
+		}
+		return m, waitForConfig(m.configSub)
+
+	case configReloadMsg: // the new config itself comes as configMsg
+		if msg.err != nil {
+			m.handleScroll(formatSystemMessage("Config not applied, keeping the last good one: " + msg.err.Error()))
+		} else {
+			m.handleScroll(formatSystemMessage("Config reloaded"))
+		}
+		return m, waitForConfigReload(m.reloads)
+
+	case chatBatchMsg: // print all chat messages of the frame at once
+		m.handleBatch(msg)
+		return m, waitForChatBatch(m.twitch.MsgChan, m.plugins, time.Now())
+
+	case twitch.ChatMessage: // print our own sent message
 		m.handleScroll(msg)
-		return m, waitForChatMsg(m.twitch.MsgChan)
+		return m, nil
 
 	case tea.KeyMsg: // user key press
 		return m.handleKey(msg)
@@ -143,6 +258,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handle keypresses that are not in the textbox (shortcuts)
 func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.search != nil {
+		if msg.Type != tea.KeyCtrlC && msg.Type != tea.KeyCtrlQ { // quitting works from the search too
+			return m.handleSearchKey(msg)
+		}
+		m.cancelHistorySearch()
+	}
+	if msg.String() != "tab" && msg.String() != "shift+tab" { // any other key ends the completion
+		m.completion = nil
+	}
+
 	switch msg.String() {
 	case "ctrl+q":
 		return m, tea.Quit
@@ -168,6 +293,28 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		return m.handleEnter()
 
+	case "up", "down": // step through the chat / command history
+		if m.state == stateInputChat || m.state == stateInputCommand {
+			if msg.String() == "up" {
+				m.recallHistory(-1)
+			} else {
+				m.recallHistory(1)
+			}
+			return m, nil
+		}
+
+	case "tab", "shift+tab": // complete commands, arguments, @users and emotes
+		if m.state == stateInputChat || m.state == stateInputCommand {
+			m.handleTab(msg.String() == "shift+tab")
+			return m, nil
+		}
+
+	case "ctrl+r": // search the history
+		if m.state == stateInputChat || m.state == stateInputCommand {
+			m.startHistorySearch()
+			return m, nil
+		}
+
 	case "i": // switch to input state
 		if m.state == stateView {
 			m.state = stateInputChat
@@ -185,21 +332,32 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc": // switch form input / command to view state
 		if m.state == stateInputChat || m.state == stateInputCommand {
 			m.state = stateView
+			m.replyTo = nil
+			m.historyPos = -1
 			m.textInput.Blur()
 			m.textInput.Reset()
 			return m, nil
 		}
 	}
 
-	var tiCmd, vpCmd tea.Cmd
-	m.textInput, tiCmd = m.textInput.Update(msg)
-	m.updateInputState()
-
 	if m.state == stateView {
-		m.viewport, vpCmd = m.viewport.Update(msg)
+		if m.redemptions != nil {
+			if cmd, ok := m.handleRedemptionKey(msg); ok {
+				return m, cmd
+			}
+		}
+		if cmd, ok := m.handleCursorKey(msg); ok {
+			return m, cmd
+		}
+		m.handleViewportKey(msg)
+		return m, nil
 	}
 
-	return m, tea.Batch(tiCmd, vpCmd)
+	var tiCmd tea.Cmd
+	m.textInput, tiCmd = m.textInput.Update(msg)
+	m.updateInputState()
+
+	return m, tiCmd
 }
 
 // set the input as focused, add the prefill, set the ui state
@@ -215,6 +373,8 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 	input := strings.TrimSpace(m.textInput.Value())
 
 	if strings.HasPrefix(input, ":") {
+		m.rememberInput(input)
+		m.replyTo = nil
 		m.textInput.Reset()
 		m.state = stateView
 		m.textInput.Blur()
@@ -228,23 +388,28 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 		}
 		m.twitch.CurrentChannel = input
 		m.twitch.ChannelID = ""
-		m.config.Twitch.Channel = input
-		m.config.Twitch.ChannelID = ""
-		m.config.Twitch.UserID = m.twitch.UserID
-		if err := config.UpdateConfig(m.config); err != nil {
-			m.handleScroll(formatSystemMessage("Failed to save config: " + err.Error()))
-		}
+		m.updateConfig(func(cfg *config.Config) {
+			cfg.Twitch.Channel = input
+			cfg.Twitch.ChannelID = ""
+		})
 		m.textInput.Reset()
 		m.textInput.Placeholder = "Send a message..."
 		m.state = stateView
 		m.textInput.Blur()
-		return m, tea.Batch(m.connectCmd(), waitForChatMsg(m.twitch.MsgChan))
+		m.plugins.SetChannel(input)
+		m.plugins.Event("channel.join", input)
+		return m, tea.Batch(m.connectCmd(), waitForChatBatch(m.twitch.MsgChan, m.plugins, time.Time{}))
 
 	case stateInputChat, stateInputCommand:
 		if input != "" {
+			m.rememberInput(input)
 			m.textInput.Reset()
 			m.state = stateView
 			m.textInput.Blur()
+			if parent := m.replyTo; parent != nil {
+				m.replyTo = nil
+				return m, m.sendReplyCmd(*parent, input)
+			}
 			return m, m.sendMsgCmd(input)
 		}
 	}
@@ -260,41 +425,17 @@ func (m Model) View() string {
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		m.headerView(),
-		m.viewport.View(),
+		m.overlayCompletion(m.chatView()),
 		m.footerView(),
 	)
 }
 
-// handle the auto scroll down - on message - but disable when user scrolls up
-func (m *Model) handleScroll(msg twitch.ChatMessage) {
-	atBottom := m.viewport.AtBottom()
-	m.messages = append(m.messages, msg)
-	m.viewport.SetContent(m.buildContent())
-	if atBottom {
-		m.viewport.GotoBottom()
-	}
-}
-
-// build the message view
-func (m Model) buildContent() string {
-	var sb strings.Builder
-	// go through all the messages and apply the filter - when avaiable - and then print them
-	for _, msg := range m.messages {
-		if m.filter != "" && !strings.Contains(strings.ToLower(msg.Content), strings.ToLower(m.filter)) {
-			continue
-		}
-		sb.WriteString(m.formatMessage(msg))
-	}
-	return sb.String()
-}
-
-// handle the auto scroll down - on viewport change - but disable when user scrolls up
-func (m *Model) refreshViewport() {
-	atBottom := m.viewport.AtBottom()
-	m.viewport.SetContent(m.buildContent())
-	if atBottom {
-		m.viewport.GotoBottom()
+// the chat or the panel that replaces it
+func (m Model) chatView() string {
+	if m.redemptions != nil {
+		return m.redemptionView()
 	}
+	return m.viewport.View()
 }
 
 // switch the ui state depending on the input - : = command
@@ -332,10 +473,26 @@ func (m *Model) sendMsgCmd(content string) tea.Cmd {
 	return func() tea.Msg {
 		m.twitch.Say(content)
 		return twitch.ChatMessage{
-			Time:    time.Now(),
-			User:    m.config.Twitch.User,
-			Flare:   "TUI",
-			Content: content,
+			Time:      time.Now(),
+			User:      m.config.Twitch.User,
+			Flare:     "TUI",
+			Content:   content,
+			NameColor: m.twitch.UserColor(m.config.Twitch.User),
+		}
+	}
+}
+
+// answer a message in its reply thread
+func (m *Model) sendReplyCmd(parent twitch.ChatMessage, content string) tea.Cmd {
+	return func() tea.Msg {
+		m.twitch.Reply(parent.ID, content)
+		return twitch.ChatMessage{
+			Time:      time.Now(),
+			User:      m.config.Twitch.User,
+			Flare:     "TUI",
+			Prepend:   "↪ @" + parent.User,
+			Content:   content,
+			NameColor: m.twitch.UserColor(m.config.Twitch.User),
 		}
 	}
 }
@@ -367,9 +524,68 @@ func waitForSystemMsg(sub chan string) tea.Cmd {
 	}
 }
 
-func waitForChatMsg(sub chan twitch.ChatMessage) tea.Cmd {
+// wait until the next frame, then drain everything that is queued as one batch
+// the plugins see every message before the tui does
+func waitForChatBatch(sub chan twitch.ChatMessage, pm *plugins.Manager, lastFrame time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if wait := frameInterval - time.Since(lastFrame); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		batch := chatBatchMsg{<-sub}
+	drain:
+		for len(batch) < maxBatchSize {
+			select {
+			case msg := <-sub:
+				batch = append(batch, msg)
+			default:
+				break drain
+			}
+		}
+
+		return chatBatchMsg(pm.ProcessBatch(batch))
+	}
+}
+
+// change the config in the store - saving errors are shown, the change is used either way
+func (m *Model) updateConfig(change func(cfg *config.Config)) {
+	err := m.store.Update(func(cfg *config.Config) error {
+		change(cfg)
+		return nil
+	})
+	if err != nil {
+		m.handleScroll(formatSystemMessage("Failed to save config: " + err.Error()))
+	}
+	m.applyConfig(m.store.Get())
+}
+
+// take over a new config - the parts that are not read on every use get updated here
+// theme and date format are part of the render key, the chat redraws with them on its own
+func (m *Model) applyConfig(cfg config.Config) {
+	old := m.config
+	m.config = cfg
+	m.badgeKey = fmt.Sprint(cfg.Badges)
+	m.messages.Resize(cfg.Chat.Scrollback)
+	m.history.SetLimit(cfg.Chat.HistorySize)
+
+	if !maps.Equal(old.Aliases, cfg.Aliases) || !reflect.DeepEqual(old.Macros, cfg.Macros) {
+		for _, warning := range registerUserCommands(cfg) {
+			m.pushMessage(formatSystemMessage("Config commands: " + warning))
+		}
+	}
+
+	m.refreshViewport()
+}
+
+func waitForConfig(sub <-chan config.Config) tea.Cmd {
+	return func() tea.Msg {
+		return configMsg(<-sub)
+	}
+}
+
+func waitForConfigReload(reloads <-chan error) tea.Cmd {
 	return func() tea.Msg {
-		return <-sub
+		return configReloadMsg{<-reloads}
 	}
 }
 
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-tui/internal/twitch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commands for moderators - they go through the helix api
+func moderationCommands() []commandDef {
+	user := argSpec{Name: "user", Complete: completeChatters}
+	reason := argSpec{Name: "reason", Optional: true, Rest: true}
+
+	return []commandDef{
+		{
+			Name:        "ban",
+			Description: "ban a user from the channel",
+			Args:        []argSpec{user, reason},
+			Examples:    []string{":ban spammer", ":ban spammer posting links"},
+			Handle:      handleBanCommand,
+		},
+		{
+			Name:        "timeout",
+			Aliases:     []string{"to"},
+			Description: "time out a user - plain numbers are seconds",
+			Args:        []argSpec{user, {Name: "duration", Help: "e.g. 600, 10m, 1h30m, 1d (max 2w)"}, reason},
+			Examples:    []string{":timeout spammer 10m", ":timeout spammer 600 calm down"},
+			Handle:      handleTimeoutCommand,
+		},
+		{
+			Name:        "unban",
+			Description: "lift the ban or timeout of a user",
+			Args:        []argSpec{user},
+			Handle:      handleUnbanCommand,
+		},
+		{
+			Name:        "delete",
+			Description: "delete the message under the cursor",
+			Handle:      handleDeleteCommand,
+		},
+		{
+			Name:        "clear",
+			Description: "delete all messages in the channel",
+			Handle:      handleClearCommand,
+		},
+	}
+}
+
+func handleBanCommand(m *Model, args []string) (tea.Cmd, error) {
+	user := strings.TrimPrefix(args[0], "@")
+	reason := strings.Join(args[1:], " ")
+	return m.moderationCmd("Banned "+user, func() error {
+		return m.twitch.Ban(user, 0, reason)
+	}), nil
+}
+
+func handleTimeoutCommand(m *Model, args []string) (tea.Cmd, error) {
+	user := strings.TrimPrefix(args[0], "@")
+	duration, err := parseTimeout(args[1])
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.Join(args[2:], " ")
+	return m.moderationCmd(fmt.Sprintf("Timed out %s for %s", user, duration), func() error {
+		return m.twitch.Ban(user, duration, reason)
+	}), nil
+}
+
+func handleUnbanCommand(m *Model, args []string) (tea.Cmd, error) {
+	user := strings.TrimPrefix(args[0], "@")
+	return m.moderationCmd("Unbanned "+user, func() error {
+		return m.twitch.Unban(user)
+	}), nil
+}
+
+func handleDeleteCommand(m *Model, _ []string) (tea.Cmd, error) {
+	entry := m.cursorEntry()
+	if entry == nil || entry.msg.ID == "" {
+		return nil, errors.New("no chat message under the cursor - move it with j/k first")
+	}
+
+	msg := entry.msg
+	return m.moderationCmd("Deleted message of "+msg.User, func() error {
+		return m.twitch.DeleteMessage(msg.ID)
+	}), nil
+}
+
+func handleClearCommand(m *Model, _ []string) (tea.Cmd, error) {
+	return m.moderationCmd("Chat cleared", func() error {
+		return m.twitch.ClearChat()
+	}), nil
+}
+
+// run the helix call in the background and report how it went
+func (m *Model) moderationCmd(success string, call func() error) tea.Cmd {
+	return func() tea.Msg {
+		if err := call(); err != nil {
+			return noticeMsg("Moderation failed: " + err.Error())
+		}
+		return noticeMsg(success)
+	}
+}
+
+// timeout durations - plain numbers are seconds, go durations and d / w suffixes work too
+// at most twitch.MaxTimeout - counts are checked before they are multiplied so huge ones can not wrap around
+func parseTimeout(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	tooLong := fmt.Errorf("duration %q is longer than the 2 weeks (%ds) twitch allows", value, int(twitch.MaxTimeout/time.Second))
+
+	plainSeconds := value != "" && strings.Trim(value, "0123456789") == "" // isDigits would send too long numbers to ParseDuration
+
+	var duration time.Duration
+	switch {
+	case plainSeconds, strings.HasSuffix(value, "d"), strings.HasSuffix(value, "w"):
+		number, unit := value, time.Second
+		switch value[len(value)-1] {
+		case 'd':
+			number, unit = value[:len(value)-1], 24*time.Hour
+		case 'w':
+			number, unit = value[:len(value)-1], 7*24*time.Hour
+		}
+		count, err := strconv.ParseInt(number, 10, 64)
+		if errors.Is(err, strconv.ErrRange) && !strings.HasPrefix(number, "-") {
+			return 0, tooLong
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		if count > int64(twitch.MaxTimeout/unit) {
+			return 0, tooLong
+		}
+		duration = time.Duration(count) * unit
+	default:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		duration = parsed
+	}
+
+	if duration < time.Second {
+		return 0, errors.New("duration must be at least 1s")
+	}
+	if duration > twitch.MaxTimeout {
+		return 0, tooLong
+	}
+	return duration, nil
+}
+
+// recent chatters for user arguments
+func completeChatters(m *Model, _ []string) []string {
+	return m.recentChatters()
+}
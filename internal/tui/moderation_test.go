@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"600", 10 * time.Minute, false},
+		{"1", time.Second, false},
+		{" 90 ", 90 * time.Second, false},
+		{"10m", 10 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"2D", 48 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1209600", 14 * 24 * time.Hour, false},
+		{"336h", 14 * 24 * time.Hour, false},
+		{"1209601", 0, true},
+		{"15d", 0, true},
+		{"3w", 0, true},
+		{"337h", 0, true},
+		{"99999999999999999999", 0, true},
+		{"99999999999999999999d", 0, true},
+		{"0", 0, true},
+		{"500ms", 0, true},
+		{"-1d", 0, true},
+		{"-5m", 0, true},
+		{"", 0, true},
+		{"d", 0, true},
+		{"x", 0, true},
+		{"1.5d", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeout(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeout(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimeout(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"twitch-tui/internal/plugins"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type pluginActionMsg plugins.Action
+
+// answer of a plugin command
+type pluginResultMsg struct {
+	plugin string
+	result plugins.ExecuteResult
+	err    error
+}
+
+// command names owned by a plugin - a restarted plugin may register them again
+var pluginCommands = make(map[string]string)
+
+func waitForPluginAction(sub chan plugins.Action) tea.Cmd {
+	return func() tea.Msg {
+		return pluginActionMsg(<-sub)
+	}
+}
+
+// do what the plugin asked for
+func (m *Model) handlePluginAction(action plugins.Action) tea.Cmd {
+	switch action.Kind {
+	case plugins.ActionSend:
+		return m.sendMsgCmd(action.Text)
+
+	case plugins.ActionNotice:
+		m.handleScroll(formatSystemMessage(action.Text))
+
+	case plugins.ActionRegister:
+		name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(action.Command.Name), ":"))
+		if name == "" || strings.ContainsAny(name, " \t") {
+			m.handleScroll(formatSystemMessage(fmt.Sprintf("Plugin %s: invalid command name %q", action.Plugin, action.Command.Name)))
+			return nil
+		}
+		if _, taken := commandRegistry[name]; taken && pluginCommands[name] != action.Plugin {
+			m.handleScroll(formatSystemMessage(fmt.Sprintf("Plugin %s: :%s already exists - skipped", action.Plugin, name)))
+			return nil
+		}
+
+		action.Command.Name = name
+		if _, taken := commandRegistry[name]; !taken {
+			registerCommand(pluginCommand(action.Plugin, action.Command))
+		} else {
+			commandRegistry[name] = pluginCommand(action.Plugin, action.Command)
+		}
+		pluginCommands[name] = action.Plugin
+	}
+
+	return nil
+}
+
+// show the output of a plugin command and send what it wants to send
+func (m *Model) handlePluginResult(msg pluginResultMsg) tea.Cmd {
+	if msg.err != nil {
+		m.handleScroll(formatSystemMessage(fmt.Sprintf("Plugin %s: %v", msg.plugin, msg.err)))
+		return nil
+	}
+
+	if output := strings.TrimSpace(msg.result.Output); output != "" {
+		m.handleScroll(formatSystemMessage(output))
+	}
+
+	var cmds []tea.Cmd
+	for _, text := range msg.result.Send {
+		cmds = append(cmds, m.sendMsgCmd(text))
+	}
+	return tea.Sequence(cmds...)
+}
+
+// : command that is answered by a plugin
+func pluginCommand(plugin string, command plugins.Command) commandDef {
+	description := command.Description
+	if description == "" {
+		description = "provided by plugin " + plugin
+	}
+
+	return commandDef{
+		Name:        command.Name,
+		Description: description,
+		Args:        []argSpec{{Name: "args", Help: fallback(command.Usage, "passed to the plugin"), Optional: true, Rest: true}},
+		Handle: func(m *Model, args []string) (tea.Cmd, error) {
+			pm := m.plugins
+			return func() tea.Msg {
+				result, err := pm.Execute(plugin, command.Name, args)
+				return pluginResultMsg{plugin: plugin, result: result, err: err}
+			}, nil
+		},
+	}
+}
+
+// stop everything that runs in the background
+func (m *Model) Close() {
+	m.stopWatch()
+	m.plugins.Stop()
+	m.twitch.Close()
+}
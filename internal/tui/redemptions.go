@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"twitch-tui/internal/twitch"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// queue of unfulfilled channel point redemptions - shown instead of the chat while open
+type redemptionPanel struct {
+	items   []twitch.Redemption
+	index   int
+	loading bool
+	busy    map[string]bool // redemptions with a running fulfil / refund
+	err     error
+}
+
+type redemptionsLoadedMsg struct {
+	items []twitch.Redemption
+	err   error
+}
+
+type redemptionDoneMsg struct {
+	redemption twitch.Redemption
+	refund     bool
+	err        error
+}
+
+// the channel point queue - only the broadcaster can manage it
+func redemptionCommands() []commandDef {
+	return []commandDef{
+		{
+			Name:        "redemptions",
+			Description: "queue of pending channel point redemptions to fulfil or refund",
+			Handle:      handleRedemptionsCommand,
+		},
+	}
+}
+
+// open the queue and load the pending redemptions
+func handleRedemptionsCommand(m *Model, _ []string) (tea.Cmd, error) {
+	m.redemptions = &redemptionPanel{loading: true, busy: make(map[string]bool)}
+	return m.loadRedemptionsCmd(), nil
+}
+
+func (m *Model) loadRedemptionsCmd() tea.Cmd {
+	return func() tea.Msg {
+		items, err := m.twitch.PendingRedemptions()
+		return redemptionsLoadedMsg{items: items, err: err}
+	}
+}
+
+func (m *Model) handleRedemptionsLoaded(msg redemptionsLoadedMsg) {
+	p := m.redemptions
+	if p == nil {
+		return
+	}
+	p.loading = false
+	p.err = msg.err
+	p.items = msg.items
+	p.index = min(p.index, max(len(p.items)-1, 0))
+}
+
+// remove the redemption from the queue once twitch took it
+func (m *Model) handleRedemptionDone(msg redemptionDoneMsg) {
+	action := "Fulfilled"
+	if msg.refund {
+		action = "Refunded"
+	}
+	label := fmt.Sprintf("%s %s for %s", action, msg.redemption.RewardTitle, msg.redemption.User)
+
+	if msg.err != nil {
+		m.handleScroll(formatSystemMessage(fmt.Sprintf("%s failed: %v", label, msg.err)))
+	} else {
+		m.handleScroll(formatSystemMessage(label))
+	}
+
+	p := m.redemptions
+	if p == nil {
+		return
+	}
+	delete(p.busy, msg.redemption.ID)
+	if msg.err != nil {
+		return
+	}
+	for i, item := range p.items {
+		if item.ID == msg.redemption.ID {
+			p.items = append(p.items[:i], p.items[i+1:]...)
+			break
+		}
+	}
+	p.index = min(p.index, max(len(p.items)-1, 0))
+}
+
+// keys of the open queue - false lets the normal view keys handle it
+func (m *Model) handleRedemptionKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	p := m.redemptions
+
+	switch msg.String() {
+	case "j", "down":
+		p.index = min(p.index+1, max(len(p.items)-1, 0))
+	case "k", "up":
+		p.index = max(p.index-1, 0)
+	case "f", "enter":
+		return m.resolveRedemption(false), true
+	case "x":
+		return m.resolveRedemption(true), true
+	case "R":
+		p.loading = true
+		return m.loadRedemptionsCmd(), true
+	case "esc", "q":
+		m.redemptions = nil
+	default:
+		return nil, false
+	}
+
+	return nil, true
+}
+
+// fulfil or refund the selected redemption in the background
+func (m *Model) resolveRedemption(refund bool) tea.Cmd {
+	p := m.redemptions
+	if len(p.items) == 0 {
+		return nil
+	}
+	redemption := p.items[p.index]
+	if p.busy[redemption.ID] {
+		return nil
+	}
+	p.busy[redemption.ID] = true
+
+	return func() tea.Msg {
+		var err error
+		if refund {
+			err = m.twitch.RefundRedemption(redemption)
+		} else {
+			err = m.twitch.FulfillRedemption(redemption)
+		}
+		return redemptionDoneMsg{redemption: redemption, refund: refund, err: err}
+	}
+}
+
+// the queue in the size of the chat viewport
+func (m Model) redemptionView() string {
+	p := m.redemptions
+	styles := m.getStyles()
+	width, height := max(m.viewport.Width, 1), max(m.viewport.Height, 1)
+
+	title := fmt.Sprintf("Redemptions (%d)", len(p.items))
+	if p.loading {
+		title += " - loading..."
+	}
+	lines := []string{
+		styles.Mauve.Render(title) + styles.Subtext1.Render("  j/k move · f fulfil · x refund · R reload · esc close"),
+	}
+
+	switch {
+	case p.err != nil:
+		lines = append(lines, styles.Red.Render("Failed to load: "+p.err.Error()))
+	case len(p.items) == 0 && !p.loading:
+		lines = append(lines, styles.Subtext1.Render("Nothing waiting - only rewards created with this client id can be managed"))
+	}
+
+	rows := height - len(lines)
+	first := min(max(p.index-rows/2, 0), max(len(p.items)-rows, 0))
+	selected := lipgloss.NewStyle().
+		Background(lipgloss.Color(m.config.Theme.Lavender)).
+		Foreground(lipgloss.Color(m.config.Theme.Base))
+
+	for i := first; i < len(p.items) && i < first+rows; i++ {
+		item := p.items[i]
+		line := fmt.Sprintf("%s  %s - %s (%d)", item.RedeemedAt.Local().Format(m.config.Style.DateFormat), item.User, item.RewardTitle, item.Cost)
+		if item.UserInput != "" {
+			line += ": " + item.UserInput
+		}
+		if p.busy[item.ID] {
+			line += " …"
+		}
+		line = ansi.Truncate(line, width, "…")
+
+		if i == p.index {
+			line = selected.Render(line + strings.Repeat(" ", max(width-ansi.StringWidth(line), 0)))
+		} else {
+			line = styles.Text.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines[:height], "\n")
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"twitch-tui/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// switch, preview and import themes - built-in presets and the own ones in the themes directory
+func themeCommands() []commandDef {
+	return []commandDef{
+		{
+			Name:        "theme",
+			Description: "list the themes or switch to one - it is saved and applied right away",
+			Args:        []argSpec{{Name: "name", Optional: true, Complete: completeThemes}},
+			Examples:    []string{":theme", ":theme catppuccin-mocha"},
+			Handle:      handleThemeCommand,
+			Subcommands: []commandDef{
+				{
+					Name:        "preview",
+					Description: "try a theme without saving it - without a name the saved theme comes back",
+					Args:        []argSpec{{Name: "name", Optional: true, Complete: completeThemes}},
+					Examples:    []string{":theme preview nord", ":theme preview"},
+					Handle:      handleThemePreview,
+				},
+				{
+					Name:        "import",
+					Description: "save a base16 scheme file as own theme",
+					Args: []argSpec{
+						{Name: "file", Help: "base16 scheme in yaml"},
+						{Name: "name", Help: "defaults to the scheme name", Optional: true},
+						{Name: "overwrite", Help: "replace an own theme of that name", Optional: true, Choices: []string{"overwrite"}},
+					},
+					Examples: []string{":theme import ~/schemes/tomorrow-night.yaml", ":theme import ~/schemes/tomorrow-night.yaml tomorrow overwrite"},
+					Handle:   handleThemeImport,
+				},
+			},
+		},
+	}
+}
+
+func handleThemeCommand(m *Model, args []string) (tea.Cmd, error) {
+	current := m.store.Get().Theme.Preset
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString("Themes (:theme <name> to switch):")
+		for _, name := range config.ThemeNames() {
+			marker := "  "
+			if name == current {
+				marker = "* "
+			}
+			sb.WriteString("\n  " + marker + name)
+		}
+		if current == "" {
+			sb.WriteString("\n  the colours in config.toml are in use")
+		}
+		m.handleScroll(formatSystemMessage(sb.String()))
+		return nil, nil
+	}
+
+	name := strings.ToLower(args[0])
+	err := m.store.Update(func(cfg *config.Config) error {
+		return cfg.UseTheme(name)
+	})
+	m.applyConfig(m.store.Get())
+	if err != nil {
+		return nil, err
+	}
+	m.handleScroll(formatSystemMessage("Theme " + name))
+	return nil, nil
+}
+
+// the theme only goes into our copy of the config - the next config change ends the preview
+func handleThemePreview(m *Model, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		m.config.Theme = m.store.Get().Theme
+		m.refreshViewport()
+		m.handleScroll(formatSystemMessage("Preview ended"))
+		return nil, nil
+	}
+
+	name := strings.ToLower(args[0])
+	theme, err := config.LoadTheme(name)
+	if err != nil {
+		return nil, err
+	}
+	m.config.Theme = theme
+	m.refreshViewport()
+	m.handleScroll(formatSystemMessage(fmt.Sprintf("Previewing %s - :theme %s keeps it, :theme preview goes back", name, name)))
+	return nil, nil
+}
+
+func handleThemeImport(m *Model, args []string) (tea.Cmd, error) {
+	path := args[0]
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+
+	overwrite := false
+	if last := len(args) - 1; last > 0 && strings.EqualFold(args[last], "overwrite") { // :theme import <file> overwrite keeps the scheme name
+		overwrite = true
+		args = args[:last]
+	}
+	name := ""
+	if len(args) == 2 {
+		name = args[1]
+	}
+	name, written, err := config.ImportBase16(path, name, overwrite)
+	if err != nil {
+		return nil, fmt.Errorf("import failed: %v", err)
+	}
+	m.handleScroll(formatSystemMessage(fmt.Sprintf("Imported %s to %s - :theme preview %s to try it", name, written, name)))
+	return nil, nil
+}
+
+func completeThemes(m *Model, args []string) []string {
+	return config.ThemeNames()
+}
@@ -19,7 +19,10 @@ import (
 const (
 	twitchDeviceCodeURL = "https://id.twitch.tv/oauth2/device"
 	twitchTokenURL      = "https://id.twitch.tv/oauth2/token"
-	twitchScopes        = "chat:read chat:edit"
+	twitchScopes        = "chat:read chat:edit" +
+		" moderator:manage:banned_users moderator:manage:chat_messages moderator:manage:chat_settings moderator:manage:shield_mode" +
+		" moderator:read:followers moderator:manage:automod" +
+		" channel:manage:redemptions channel:read:polls channel:read:predictions channel:read:hype_train"
 )
 
 type deviceCodeResponse struct {
@@ -33,16 +36,19 @@ type deviceCodeResponse struct {
 type tokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
 	Message      string `json:"message"`
 }
 
 // login user
 func (t *Service) login() {
+	t.credMu.Lock()
 	if !strings.HasPrefix(t.token, "oauth:") {
 		t.token = "oauth:" + t.token
 	}
-
 	t.client = twitch.NewClient(t.User, t.token)
+	t.credMu.Unlock()
+
 	t.Authenticated = true
 }
 
@@ -52,8 +58,8 @@ func (t *Service) loginWithDeviceCode(clientID string) error {
 		return errors.New("missing client ID")
 	}
 
-	if t.client != nil {
-		t.client.Disconnect()
+	if client := t.ircClient(); client != nil {
+		client.Disconnect()
 	}
 
 	device, err := t.startDeviceCodeFlow(clientID)
@@ -69,8 +75,7 @@ func (t *Service) loginWithDeviceCode(clientID string) error {
 		return err
 	}
 
-	t.token = tokens.AccessToken
-	t.refreshToken = tokens.RefreshToken
+	t.setTokens(tokens.AccessToken, tokens.RefreshToken)
 	t.ClientID = clientID
 
 	userID, login, validatedClientID, err := t.fetchOAuthIdentity()
@@ -86,19 +91,22 @@ func (t *Service) loginWithDeviceCode(clientID string) error {
 		t.ClientID = validatedClientID
 	}
 
-	if err := config.UpdateTokens(t.token, t.refreshToken); err != nil {
-		t.SysChan <- fmt.Sprintf("Login succeeded but failed to persist tokens: %v", err)
-	}
-	if err := config.UpdateLogin(t.User, t.token); err != nil {
-		t.SysChan <- fmt.Sprintf("Login succeeded but failed to persist user: %v", err)
-	}
-	if err := config.UpdateClientID(t.ClientID); err != nil {
-		t.SysChan <- fmt.Sprintf("Login succeeded but failed to persist client ID: %v", err)
+	err = t.store.Update(func(cfg *config.Config) error {
+		cfg.Twitch.User = t.User
+		cfg.Twitch.Oauth = tokens.AccessToken
+		cfg.Twitch.Refresh = tokens.RefreshToken
+		cfg.Twitch.ClientID = t.ClientID
+		cfg.Twitch.UserID = t.UserID
+		return nil
+	})
+	if err != nil {
+		t.SysChan <- fmt.Sprintf("Login succeeded but failed to save it: %v", err)
 	}
 
 	t.login()
 	t.SysChan <- fmt.Sprintf("OAuth login successful as %s", t.User)
 	t.startSession()
+	t.startTokenManager()
 	return nil
 }
 
@@ -117,7 +125,7 @@ func (t *Service) FetchUserID() error {
 
 	if clientID != "" && t.ClientID != clientID {
 		t.ClientID = clientID
-		_ = config.UpdateClientID(clientID)
+		_ = t.saveClientID(clientID)
 	}
 
 	return nil
@@ -143,10 +151,10 @@ func (t *Service) FetchChannelID() error {
 
 	if clientID != "" && t.ClientID != clientID {
 		t.ClientID = clientID
-		_ = config.UpdateClientID(clientID)
+		_ = t.saveClientID(clientID)
 	}
 
-	id, err := t.fetchHelixUserIDByLogin(t.CurrentChannel, clientID)
+	id, err := t.UserIDByLogin(t.CurrentChannel)
 	if err != nil {
 		return err
 	}
@@ -157,127 +165,27 @@ func (t *Service) FetchChannelID() error {
 
 // we call to the twitch oauth api to log our user in, and in the response we ge the client and user id
 func (t *Service) fetchOAuthIdentity() (string, string, string, error) {
-	accessToken := strings.TrimPrefix(t.token, "oauth:")
-	if accessToken == "" {
-		t.SysChan <- "OAuth validate failed: missing access token"
-		return "", "", "", errors.New("access token is required to validate")
-	}
-
-	req, err := http.NewRequest("GET", "https://id.twitch.tv/oauth2/validate", nil)
-	if err != nil {
-		return "", "", "", err
-	}
-	req.Header.Set("Authorization", "OAuth "+accessToken)
-
-	resp, err := http.DefaultClient.Do(req)
+	result, err := t.validateToken(t.ctx, t.AccessToken())
 	if err != nil {
-		t.SysChan <- fmt.Sprintf("OAuth validate failed: request error - %v", err)
-		return "", "", "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		msg := readBodySnippet(resp.Body)
-		t.SysChan <- fmt.Sprintf("OAuth validate failed: status=%s body=%s", resp.Status, msg)
-		return "", "", "", fmt.Errorf("failed to validate token: %s", resp.Status)
-	}
-
-	var result struct {
-		ClientID string `json:"client_id"`
-		Login    string `json:"login"`
-		UserID   string `json:"user_id"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		t.SysChan <- fmt.Sprintf("OAuth validate failed: decode error - %v", err)
 		return "", "", "", err
 	}
-
-	if result.UserID == "" {
-		t.SysChan <- "OAuth validate failed: missing user ID"
-		return "", "", "", errors.New("token validation did not return user ID")
-	}
-
-	t.SysChan <- fmt.Sprintf("OAuth validate ok: id=%s", result.UserID)
 	return result.UserID, result.Login, result.ClientID, nil
 }
 
-// call another twitch api to get a forgein user id from our client id
-func (t *Service) fetchHelixUserIDByLogin(login, clientID string) (string, error) {
-	if login == "" {
-		t.SysChan <- "Helix lookup failed: empty login"
-		return "", errors.New("login is required to fetch user ID")
-	}
-
-	url := fmt.Sprintf("https://api.twitch.tv/helix/users?login=%s", login)
-	id, _, err := t.fetchHelixUser(url, fmt.Sprintf("login=%s", login), clientID)
-	return id, err
-}
-
-// generate a request and handle the response of a twitch helix call
-func (t *Service) fetchHelixUser(url, label, clientID string) (string, string, error) {
-	if clientID == "" {
-		t.SysChan <- "Helix lookup failed: missing client ID"
-		return "", "", errors.New("client ID is required to fetch user ID")
-	}
-
-	accessToken := strings.TrimPrefix(t.token, "oauth:")
-	if accessToken == "" {
-		t.SysChan <- "Helix lookup failed: missing access token"
-		return "", "", errors.New("access token is required to fetch user ID")
-	}
-
-	t.SysChan <- fmt.Sprintf("Helix lookup: %s", label)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", "", err
-	}
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		t.SysChan <- fmt.Sprintf("Helix lookup failed: request error - %v", err)
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		msg := readBodySnippet(resp.Body)
-		t.SysChan <- fmt.Sprintf("Helix lookup failed: status=%s body=%s", resp.Status, msg)
-		return "", "", fmt.Errorf("failed to fetch user ID: %s", resp.Status)
-	}
-
-	var result struct {
-		Data []struct {
-			ID    string `json:"id"`
-			Login string `json:"login"`
-		} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		t.SysChan <- fmt.Sprintf("Helix lookup failed: decode error - %v", err)
-		return "", "", err
-	}
-
-	if len(result.Data) == 0 {
-		t.SysChan <- "Helix lookup failed: no user found"
-		return "", "", errors.New("no user found")
-	}
-
-	t.SysChan <- fmt.Sprintf("Helix lookup ok: id=%s", result.Data[0].ID)
-	return result.Data[0].ID, result.Data[0].Login, nil
+func (t *Service) saveClientID(clientID string) error {
+	return t.store.Update(func(cfg *config.Config) error {
+		cfg.Twitch.ClientID = clientID
+		return nil
+	})
 }
 
 // call the refresh token api to get a new oath token if needed
 func (t *Service) refresh() error {
-	if t.refreshToken == "" {
-		t.SysChan <- "Refresh failed: no refresh token available"
+	refreshToken := t.RefreshToken()
+	if refreshToken == "" {
 		return errors.New("no refresh token available")
 	}
 	if t.ClientID == "" {
-		t.SysChan <- "Refresh failed: missing client ID"
 		return errors.New("missing client ID")
 	}
 
@@ -289,7 +197,7 @@ func (t *Service) refresh() error {
 	data := url.Values{}
 	data.Set("client_id", t.ClientID)
 	data.Set("grant_type", "refresh_token")
-	data.Set("refresh_token", t.refreshToken)
+	data.Set("refresh_token", refreshToken)
 
 	resp, err := http.Post(
 		refreshURL,
@@ -297,7 +205,6 @@ func (t *Service) refresh() error {
 		bytes.NewBufferString(data.Encode()),
 	)
 	if err != nil {
-		t.SysChan <- fmt.Sprintf("Refresh failed: token request error - %v", err)
 		return fmt.Errorf("token request failed: %v", err)
 	}
 	defer resp.Body.Close()
@@ -305,7 +212,6 @@ func (t *Service) refresh() error {
 	var result tokenResponse
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		t.SysChan <- fmt.Sprintf("Refresh failed: could not decode response - %v", err)
 		return fmt.Errorf("failed to decode response: %v", err)
 	}
 
@@ -313,27 +219,45 @@ func (t *Service) refresh() error {
 		if result.Message == "" {
 			result.Message = resp.Status
 		}
-		t.SysChan <- fmt.Sprintf("Refresh failed: %s", result.Message)
 		return fmt.Errorf("refresh failed: %s", result.Message)
 	}
 
 	newRefresh := result.RefreshToken
 	if newRefresh == "" {
-		newRefresh = t.refreshToken
+		newRefresh = refreshToken
 	}
 
-	if err := config.UpdateTokens(result.AccessToken, newRefresh); err != nil {
-		t.SysChan <- fmt.Sprintf("Refresh successful but failed to update config.toml: %v", err)
+	err = t.store.Update(func(cfg *config.Config) error {
+		cfg.Twitch.Oauth = result.AccessToken
+		cfg.Twitch.Refresh = newRefresh
+		return nil
+	})
+	if err != nil {
+		t.SysChan <- fmt.Sprintf("Refresh successful but failed to save the tokens: %v", err)
 	}
 
-	t.token = result.AccessToken
-	t.refreshToken = newRefresh
-	if t.client != nil {
-		t.client.Disconnect()
+	t.setTokens(result.AccessToken, newRefresh)
+
+	t.tokens.mu.Lock()
+	t.tokens.status.RefreshedAt = time.Now()
+	if result.ExpiresIn > 0 {
+		t.tokens.status.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
 	}
-	t.login()
+	t.tokens.mu.Unlock()
 
-	t.SysChan <- "Token refreshed successfully! Reconnecting..."
+	t.SysChan <- "Token refreshed"
+	return nil
+}
+
+// the irc server rejected our token - refresh it and connect again
+func (t *Service) reconnect() error {
+	if err := t.renewToken(t.AccessToken()); err != nil {
+		return err
+	}
+	if client := t.ircClient(); client != nil {
+		client.Disconnect()
+	}
+	t.login()
 	t.startSession()
 	return nil
 }
@@ -0,0 +1,51 @@
+package twitch
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// a chat badge like subscriber/3024 - twitch sends them in the order the chat shows them
+type Badge struct {
+	Name    string
+	Version string // tier, bit amount or prediction outcome like blue-1
+	Months  int    // tenure from badge-info for subscriber and founder - 0 when unknown
+}
+
+// name/version like the irc tag
+func (b Badge) String() string {
+	return b.Name + "/" + b.Version
+}
+
+// badges of the badges and badge-info tags - the parsed map of the irc library when the tag is missing
+// the map loses the order and versions that are no number, so the raw tag is preferred
+func parseBadges(badgesTag, infoTag string, parsed map[string]int) []Badge {
+	months := map[string]int{}
+	for _, info := range strings.Split(infoTag, ",") {
+		name, value, ok := strings.Cut(info, "/")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err == nil {
+			months[name] = n
+		}
+	}
+
+	var badges []Badge
+	if badgesTag != "" {
+		for _, badge := range strings.Split(badgesTag, ",") {
+			name, version, _ := strings.Cut(badge, "/")
+			if name != "" {
+				badges = append(badges, Badge{Name: name, Version: version, Months: months[name]})
+			}
+		}
+		return badges
+	}
+
+	for name, version := range parsed {
+		badges = append(badges, Badge{Name: name, Version: strconv.Itoa(version), Months: months[name]})
+	}
+	slices.SortFunc(badges, func(a, b Badge) int { return strings.Compare(a.Name, b.Name) })
+	return badges
+}
@@ -0,0 +1,78 @@
+package twitch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBadges(t *testing.T) {
+	tests := []struct {
+		name   string
+		badges string
+		info   string
+		parsed map[string]int
+		want   []Badge
+	}{
+		{
+			name:   "keeps the tag order",
+			badges: "moderator/1,subscriber/3012,bits/1000",
+			parsed: map[string]int{"bits": 1000, "moderator": 1, "subscriber": 3012},
+			want: []Badge{
+				{Name: "moderator", Version: "1"},
+				{Name: "subscriber", Version: "3012"},
+				{Name: "bits", Version: "1000"},
+			},
+		},
+		{
+			name:   "months from badge-info",
+			badges: "subscriber/12,founder/0",
+			info:   "subscriber/14,founder/30",
+			want: []Badge{
+				{Name: "subscriber", Version: "12", Months: 14},
+				{Name: "founder", Version: "0", Months: 30},
+			},
+		},
+		{
+			name:   "broken badge-info",
+			badges: "subscriber/12",
+			info:   "subscriber/many,vip",
+			want:   []Badge{{Name: "subscriber", Version: "12"}},
+		},
+		{
+			name:   "version that is no number",
+			badges: "predictions/pink-2,vip/1",
+			parsed: map[string]int{"vip": 1},
+			want: []Badge{
+				{Name: "predictions", Version: "pink-2"},
+				{Name: "vip", Version: "1"},
+			},
+		},
+		{
+			name:   "badge without version",
+			badges: "partner,/1",
+			want:   []Badge{{Name: "partner"}},
+		},
+		{
+			name:   "map when the tag is missing",
+			info:   "subscriber/5",
+			parsed: map[string]int{"vip": 1, "subscriber": 6, "bits": 100},
+			want: []Badge{
+				{Name: "bits", Version: "100"},
+				{Name: "subscriber", Version: "6", Months: 5},
+				{Name: "vip", Version: "1"},
+			},
+		},
+		{
+			name: "no badges",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBadges(tt.badges, tt.info, tt.parsed); !slices.Equal(got, tt.want) {
+				t.Errorf("parseBadges(%q, %q) = %v, want %v", tt.badges, tt.info, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package twitch
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	MinSlowMode      = 3                // seconds
+	MaxSlowMode      = 120              // seconds
+	MaxFollowersMode = 3 * 30 * 24 * 60 // minutes - 3 months
+)
+
+// modes of the current channel - filled from ROOMSTATE and the helix answers
+type ChatSettings struct {
+	EmoteOnly        bool
+	FollowersOnly    bool
+	FollowersMinutes int // how long users must follow - 0 is any follower
+	SlowSeconds      int // 0 when slow mode is off
+	SubOnly          bool
+	Unique           bool
+	Shield           bool
+}
+
+type chatSettingsState struct {
+	mu       sync.Mutex
+	settings ChatSettings
+}
+
+// the modes we know of for the current channel
+func (t *Service) ChatSettings() ChatSettings {
+	t.chatSettings.mu.Lock()
+	defer t.chatSettings.mu.Unlock()
+	return t.chatSettings.settings
+}
+
+// change the stored settings and tell the tui - it reads the latest state with ChatSettings
+func (t *Service) updateChatSettings(change func(*ChatSettings)) {
+	t.chatSettings.mu.Lock()
+	change(&t.chatSettings.settings)
+	settings := t.chatSettings.settings
+	t.chatSettings.mu.Unlock()
+
+	select {
+	case t.SettingsChan <- settings:
+	default: // the tui still has an update queued
+	}
+}
+
+// ROOMSTATE on join has every tag, later ones only the tag that changed
+func (t *Service) applyRoomState(state map[string]int) {
+	t.updateChatSettings(func(s *ChatSettings) {
+		if value, ok := state["emote-only"]; ok {
+			s.EmoteOnly = value == 1
+		}
+		if value, ok := state["followers-only"]; ok {
+			s.FollowersOnly = value >= 0
+			s.FollowersMinutes = max(value, 0)
+		}
+		if value, ok := state["r9k"]; ok {
+			s.Unique = value == 1
+		}
+		if value, ok := state["slow"]; ok {
+			s.SlowSeconds = value
+		}
+		if value, ok := state["subs-only"]; ok {
+			s.SubOnly = value == 1
+		}
+	})
+}
+
+func (t *Service) SetSlowMode(seconds int) error {
+	if seconds != 0 && (seconds < MinSlowMode || seconds > MaxSlowMode) {
+		return errors.New("slow mode must be between 3 and 120 seconds")
+	}
+	body := map[string]any{"slow_mode": seconds > 0}
+	if seconds > 0 {
+		body["slow_mode_wait_time"] = seconds
+	}
+	return t.patchChatSettings(body)
+}
+
+func (t *Service) SetSubOnly(enable bool) error {
+	return t.patchChatSettings(map[string]any{"subscriber_mode": enable})
+}
+
+func (t *Service) SetEmoteOnly(enable bool) error {
+	return t.patchChatSettings(map[string]any{"emote_mode": enable})
+}
+
+func (t *Service) SetUniqueChat(enable bool) error {
+	return t.patchChatSettings(map[string]any{"unique_chat_mode": enable})
+}
+
+// minutes is how long users must follow before they can chat
+func (t *Service) SetFollowersOnly(enable bool, minutes int) error {
+	if minutes < 0 || minutes > MaxFollowersMode {
+		return errors.New("followers mode duration can be at most 3 months")
+	}
+	body := map[string]any{"follower_mode": enable}
+	if enable {
+		body["follower_mode_duration"] = minutes
+	}
+	return t.patchChatSettings(body)
+}
+
+// turn shield mode on or off - twitch does not send it over irc so we keep track here
+func (t *Service) SetShieldMode(enable bool) error {
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+
+	active, err := t.helix.UpdateShieldMode(t.ctx, broadcasterID, moderatorID, enable)
+	if err != nil {
+		return err
+	}
+	t.updateChatSettings(func(s *ChatSettings) { s.Shield = active })
+	return nil
+}
+
+// ask for the shield mode state - only moderators may do that so errors are expected
+func (t *Service) FetchShieldMode() error {
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+
+	active, err := t.helix.ShieldMode(t.ctx, broadcasterID, moderatorID)
+	if err != nil {
+		return err
+	}
+	t.updateChatSettings(func(s *ChatSettings) { s.Shield = active })
+	return nil
+}
+
+// send the changed settings - helix answers with all of them
+func (t *Service) patchChatSettings(body map[string]any) error {
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+
+	data, err := t.helix.UpdateChatSettings(t.ctx, broadcasterID, moderatorID, body)
+	if err != nil {
+		return err
+	}
+
+	t.updateChatSettings(func(s *ChatSettings) {
+		s.EmoteOnly = data.EmoteMode
+		s.FollowersOnly = data.FollowerMode
+		s.FollowersMinutes = 0
+		if data.FollowerModeDuration != nil {
+			s.FollowersMinutes = *data.FollowerModeDuration
+		}
+		s.SlowSeconds = 0
+		if data.SlowMode && data.SlowModeWaitTime != nil {
+			s.SlowSeconds = *data.SlowModeWaitTime
+		}
+		s.SubOnly = data.SubscriberMode
+		s.Unique = data.UniqueChatMode
+	})
+	return nil
+}
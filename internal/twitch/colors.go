@@ -0,0 +1,62 @@
+package twitch
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+const maxUserColors = 20000 // chatters remembered per channel - the channel starts over when it has more
+
+// colours of the chatters per channel - the twitch colour once we saw it, a stable pick from the theme until then
+type userColors struct {
+	mu       sync.Mutex
+	channels map[string]map[string]string // channel -> login -> colour from twitch
+}
+
+func (c *userColors) remember(channel, user, color string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channels == nil {
+		c.channels = map[string]map[string]string{}
+	}
+	users := c.channels[channel]
+	if users == nil || len(users) >= maxUserColors {
+		users = map[string]string{}
+		c.channels[channel] = users
+	}
+	users[user] = color
+}
+
+func (c *userColors) lookup(channel, user string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	color, ok := c.channels[channel][user]
+	return color, ok
+}
+
+// colour of a user in a channel - twitchColor is the one the message came with, empty when it had none
+func (t *Service) userColor(channel, user, twitchColor string) string {
+	channel, user = strings.ToLower(channel), strings.ToLower(user)
+	if twitchColor != "" {
+		t.colors.remember(channel, user, twitchColor)
+		return twitchColor
+	}
+	if color, ok := t.colors.lookup(channel, user); ok {
+		return color
+	}
+	return t.hashColor(user)
+}
+
+// colour of a user in the current channel - for our own messages and mentions the tui adds
+func (t *Service) UserColor(user string) string {
+	return t.userColor(t.CurrentChannel, user, "")
+}
+
+// the same theme colour for the same name on every message
+func (t *Service) hashColor(user string) string {
+	palette := t.palette()
+	hash := fnv.New32a()
+	hash.Write([]byte(user))
+	return palette[hash.Sum32()%uint32(len(palette))]
+}
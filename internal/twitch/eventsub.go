@@ -0,0 +1,287 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"twitch-tui/internal/helix"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	eventSubDialTimeout  = 10 * time.Second
+	eventSubKeepalive    = 10 * time.Second // used until the welcome tells us the real one
+	eventSubGrace        = 5 * time.Second  // extra time on top of the keepalive before we call the connection dead
+	eventSubMaxBackoff   = 2 * time.Minute
+	eventSubReadLimit    = 1 << 20
+	eventSubSeenMessages = 256 // twitch may resend a notification - remember the last ids
+)
+
+// envelope of every eventsub websocket message
+type eventSubMessage struct {
+	Metadata struct {
+		MessageID        string `json:"message_id"`
+		MessageType      string `json:"message_type"`
+		SubscriptionType string `json:"subscription_type"`
+	} `json:"metadata"`
+	Payload struct {
+		Session struct {
+			ID                      string `json:"id"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"subscription"`
+		Event json.RawMessage `json:"event"`
+	} `json:"payload"`
+}
+
+// a subscription we create for the channel we are in
+type eventSubTopic struct {
+	Type      string
+	Version   string
+	Moderator bool // condition also needs our user id
+}
+
+var eventSubTopics = []eventSubTopic{
+	{Type: "channel.follow", Version: "2", Moderator: true},
+	{Type: "channel.channel_points_custom_reward_redemption.add", Version: "1"},
+	{Type: "channel.poll.begin", Version: "1"},
+	{Type: "channel.poll.end", Version: "1"},
+	{Type: "channel.prediction.begin", Version: "1"},
+	{Type: "channel.prediction.lock", Version: "1"},
+	{Type: "channel.prediction.end", Version: "1"},
+	{Type: "channel.hype_train.begin", Version: "1"},
+	{Type: "channel.hype_train.progress", Version: "1"},
+	{Type: "channel.hype_train.end", Version: "1"},
+	{Type: "automod.message.hold", Version: "1", Moderator: true},
+}
+
+// websocket session for one channel - replaced when the channel changes
+type eventSub struct {
+	mu            sync.Mutex
+	broadcasterID string
+	cancel        context.CancelFunc
+
+	seen      map[string]bool
+	seenOrder []string
+	hypeLevel int
+}
+
+// connect eventsub for the current channel - does nothing when it already runs for it
+func (t *Service) startEventSub() {
+	if !t.cfg().EventSub.Enable || !t.Authenticated || t.ChannelID == "" || t.UserID == "" {
+		return
+	}
+
+	t.eventSub.mu.Lock()
+	defer t.eventSub.mu.Unlock()
+	if t.eventSub.cancel != nil && t.eventSub.broadcasterID == t.ChannelID {
+		return
+	}
+	if t.eventSub.cancel != nil {
+		t.eventSub.cancel() // subscriptions of the old session end with its connection
+	}
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	t.eventSub.cancel = cancel
+	t.eventSub.broadcasterID = t.ChannelID
+	go t.runEventSub(ctx, t.ChannelID, t.UserID)
+}
+
+func (t *Service) stopEventSub() {
+	t.eventSub.mu.Lock()
+	defer t.eventSub.mu.Unlock()
+	if t.eventSub.cancel != nil {
+		t.eventSub.cancel()
+		t.eventSub.cancel = nil
+		t.eventSub.broadcasterID = ""
+	}
+}
+
+// keep a session running - every new session needs new subscriptions
+func (t *Service) runEventSub(ctx context.Context, broadcasterID, userID string) {
+	backoff := time.Second
+	for {
+		conn, keepalive, sessionID, err := t.dialEventSub(ctx, t.cfg().EventSub.URL)
+		if err == nil {
+			backoff = time.Second
+			t.subscribeEventSub(ctx, sessionID, broadcasterID, userID)
+			err = t.listenEventSub(ctx, conn, keepalive)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.SysChan <- fmt.Sprintf("EventSub disconnected: %v - reconnecting in %s", err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, eventSubMaxBackoff)
+	}
+}
+
+// connect and wait for the welcome message
+func (t *Service) dialEventSub(ctx context.Context, url string) (*websocket.Conn, time.Duration, string, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, eventSubDialTimeout)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(dialCtx, url, nil)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	conn.SetReadLimit(eventSubReadLimit)
+
+	msg, err := readEventSub(dialCtx, conn)
+	if err != nil {
+		conn.CloseNow()
+		return nil, 0, "", err
+	}
+	if msg.Metadata.MessageType != "session_welcome" {
+		conn.CloseNow()
+		return nil, 0, "", fmt.Errorf("expected welcome, got %s", msg.Metadata.MessageType)
+	}
+
+	keepalive := eventSubKeepalive
+	if seconds := msg.Payload.Session.KeepaliveTimeoutSeconds; seconds > 0 {
+		keepalive = time.Duration(seconds) * time.Second
+	}
+	return conn, keepalive, msg.Payload.Session.ID, nil
+}
+
+// read until the connection dies - a reconnect message swaps the connection and keeps the subscriptions
+func (t *Service) listenEventSub(ctx context.Context, conn *websocket.Conn, keepalive time.Duration) error {
+	defer func() { conn.CloseNow() }()
+
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, keepalive+eventSubGrace)
+		msg, err := readEventSub(readCtx, conn)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return errors.New("keepalive timed out")
+			}
+			return err
+		}
+
+		switch msg.Metadata.MessageType {
+		case "session_keepalive":
+
+		case "notification":
+			if t.eventSubSeen(msg.Metadata.MessageID) {
+				continue
+			}
+			chat, ok := t.formatEvent(msg.Metadata.SubscriptionType, msg.Payload.Event)
+			if !ok {
+				continue
+			}
+			select {
+			case t.MsgChan <- chat:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+		case "session_reconnect":
+			next, nextKeepalive, _, err := t.dialEventSub(ctx, msg.Payload.Session.ReconnectURL)
+			if err != nil {
+				return fmt.Errorf("reconnect failed: %w", err)
+			}
+			conn.Close(websocket.StatusNormalClosure, "reconnecting")
+			conn, keepalive = next, nextKeepalive
+
+		case "revocation":
+			t.SysChan <- fmt.Sprintf("EventSub: %s subscription revoked (%s)", msg.Payload.Subscription.Type, msg.Payload.Subscription.Status)
+		}
+	}
+}
+
+// create the subscriptions for the session - most need broadcaster or moderator rights so those refusals are only counted
+// other failures are shown with their error, the same error once for all topics it hit
+func (t *Service) subscribeEventSub(ctx context.Context, sessionID, broadcasterID, userID string) {
+	subscribed, denied := 0, 0
+	var errs []string
+	failed := map[string][]string{} // error -> topics
+	for _, topic := range eventSubTopics {
+		if ctx.Err() != nil {
+			return
+		}
+
+		condition := map[string]string{"broadcaster_user_id": broadcasterID}
+		if topic.Moderator {
+			condition["moderator_user_id"] = userID
+		}
+		sub := helix.EventSubSubscription{
+			Type:      topic.Type,
+			Version:   topic.Version,
+			Condition: condition,
+			Transport: helix.EventSubTransport{Method: "websocket", SessionID: sessionID},
+		}
+
+		if err := t.helix.CreateEventSubSubscription(ctx, t.cfg().EventSub.SubscriptionURL, sub); err != nil {
+			var apiErr *helix.Error
+			if errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden) {
+				denied++
+				continue
+			}
+			if _, ok := failed[err.Error()]; !ok {
+				errs = append(errs, err.Error())
+			}
+			failed[err.Error()] = append(failed[err.Error()], topic.Type)
+			continue
+		}
+		subscribed++
+	}
+
+	status := fmt.Sprintf("EventSub connected: %d/%d events", subscribed, len(eventSubTopics))
+	if denied > 0 {
+		status += fmt.Sprintf(" (%d need broadcaster or moderator rights)", denied)
+	}
+	t.SysChan <- status
+	for _, err := range errs {
+		t.SysChan <- fmt.Sprintf("EventSub: %s failed: %s", strings.Join(failed[err], ", "), err)
+	}
+}
+
+func readEventSub(ctx context.Context, conn *websocket.Conn) (eventSubMessage, error) {
+	var msg eventSubMessage
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return msg, err
+	}
+	err = json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// true when we already handled the message
+func (t *Service) eventSubSeen(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	t.eventSub.mu.Lock()
+	defer t.eventSub.mu.Unlock()
+	if t.eventSub.seen == nil {
+		t.eventSub.seen = make(map[string]bool)
+	}
+	if t.eventSub.seen[id] {
+		return true
+	}
+
+	t.eventSub.seen[id] = true
+	t.eventSub.seenOrder = append(t.eventSub.seenOrder, id)
+	if len(t.eventSub.seenOrder) > eventSubSeenMessages {
+		delete(t.eventSub.seen, t.eventSub.seenOrder[0])
+		t.eventSub.seenOrder = t.eventSub.seenOrder[1:]
+	}
+	return false
+}
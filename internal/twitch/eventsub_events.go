@@ -0,0 +1,188 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type eventUser struct {
+	UserLogin string `json:"user_login"`
+	UserName  string `json:"user_name"`
+}
+
+type eventChoice struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Votes         int    `json:"votes"`
+	Users         int    `json:"users"`
+	ChannelPoints int    `json:"channel_points"`
+}
+
+type pollEvent struct {
+	Title   string        `json:"title"`
+	Choices []eventChoice `json:"choices"`
+	Status  string        `json:"status"`
+}
+
+type predictionEvent struct {
+	Title            string        `json:"title"`
+	Outcomes         []eventChoice `json:"outcomes"`
+	WinningOutcomeID string        `json:"winning_outcome_id"`
+	Status           string        `json:"status"`
+}
+
+type redemptionEvent struct {
+	eventUser
+	UserInput string `json:"user_input"`
+	Reward    struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Cost  int    `json:"cost"`
+	} `json:"reward"`
+}
+
+type hypeTrainEvent struct {
+	Level    int `json:"level"`
+	Total    int `json:"total"`
+	Goal     int `json:"goal"`
+	Progress int `json:"progress"`
+}
+
+type automodEvent struct {
+	eventUser
+	Category string `json:"category"`
+	Level    int    `json:"level"`
+	Message  struct {
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// turn an eventsub notification into a line for the chat - false for events we do not show
+func (t *Service) formatEvent(kind string, raw json.RawMessage) (ChatMessage, bool) {
+	msg := ChatMessage{
+		Time: time.Now(),
+		User: t.CurrentChannel,
+	}
+
+	switch kind {
+	case "channel.follow":
+		var event eventUser
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		msg.Flare = "FOLLOW"
+		msg.User = event.UserLogin
+		msg.Content = "followed the channel"
+
+	case "channel.channel_points_custom_reward_redemption.add":
+		var event redemptionEvent
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		t.rememberReward(Reward{ID: event.Reward.ID, Title: event.Reward.Title, Cost: event.Reward.Cost})
+		if event.UserInput != "" { // redemptions with a message come in over irc
+			return msg, false
+		}
+		msg.Flare = "REDEEM"
+		msg.User = event.UserLogin
+		msg.Content = fmt.Sprintf("redeemed %s (%d)", event.Reward.Title, event.Reward.Cost)
+
+	case "channel.poll.begin", "channel.poll.end":
+		var event pollEvent
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		msg.Flare = "POLL"
+		if kind == "channel.poll.begin" {
+			msg.Content = fmt.Sprintf("Poll started: %s - %s", event.Title, joinChoices(event.Choices, false))
+		} else {
+			msg.Content = fmt.Sprintf("Poll %s: %s - %s", strings.ToLower(event.Status), event.Title, joinChoices(event.Choices, true))
+		}
+
+	case "channel.prediction.begin", "channel.prediction.lock", "channel.prediction.end":
+		var event predictionEvent
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		msg.Flare = "PREDICT"
+		switch kind {
+		case "channel.prediction.begin":
+			msg.Content = fmt.Sprintf("Prediction started: %s - %s", event.Title, joinChoices(event.Outcomes, false))
+		case "channel.prediction.lock":
+			msg.Content = fmt.Sprintf("Prediction locked: %s - %s", event.Title, joinChoices(event.Outcomes, true))
+		default:
+			msg.Content = fmt.Sprintf("Prediction %s: %s", strings.ToLower(event.Status), event.Title)
+			for _, outcome := range event.Outcomes {
+				if outcome.ID == event.WinningOutcomeID {
+					msg.Content += " - " + outcome.Title + " won"
+				}
+			}
+		}
+
+	case "channel.hype_train.begin", "channel.hype_train.progress", "channel.hype_train.end":
+		var event hypeTrainEvent
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		msg.Flare = "HYPE"
+		switch kind {
+		case "channel.hype_train.begin":
+			t.setHypeLevel(event.Level)
+			msg.Content = fmt.Sprintf("Hype train started! Level %d", max(event.Level, 1))
+		case "channel.hype_train.progress":
+			if !t.setHypeLevel(event.Level) { // only level ups - progress comes with every sub and cheer
+				return msg, false
+			}
+			msg.Content = fmt.Sprintf("Hype train reached level %d!", event.Level)
+		default:
+			t.setHypeLevel(0)
+			msg.Content = fmt.Sprintf("Hype train ended at level %d", event.Level)
+		}
+
+	case "automod.message.hold":
+		var event automodEvent
+		if json.Unmarshal(raw, &event) != nil {
+			return msg, false
+		}
+		msg.Flare = "AUTOMOD"
+		msg.User = event.UserLogin
+		msg.Content = fmt.Sprintf("held (%s %d): %s", event.Category, event.Level, event.Message.Text)
+
+	default:
+		return msg, false
+	}
+
+	msg.NameColor = t.UserColor(msg.User) // the follower, redeemer or held user - the channel otherwise
+	return msg, true
+}
+
+// remember the hype train level - true when it changed
+func (t *Service) setHypeLevel(level int) bool {
+	t.eventSub.mu.Lock()
+	defer t.eventSub.mu.Unlock()
+	changed := t.eventSub.hypeLevel != level
+	t.eventSub.hypeLevel = level
+	return changed
+}
+
+// A / B / C or A 12, B 3 with results
+func joinChoices(choices []eventChoice, results bool) string {
+	parts := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		switch {
+		case !results:
+			parts = append(parts, choice.Title)
+		case choice.ChannelPoints > 0 && choice.Votes == 0:
+			parts = append(parts, fmt.Sprintf("%s %d (%d points)", choice.Title, choice.Users, choice.ChannelPoints))
+		default:
+			parts = append(parts, fmt.Sprintf("%s %d", choice.Title, choice.Votes))
+		}
+	}
+
+	if !results {
+		return strings.Join(parts, " / ")
+	}
+	return strings.Join(parts, ", ")
+}
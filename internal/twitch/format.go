@@ -13,22 +13,26 @@ import (
 func (s *Service) formatMessage(msg twitch.PrivateMessage) ChatMessage {
 	flare := resolveFlare(msg)
 
-	taggedUsers, taggedColors := extractTags(msg.Message, s.randomColor)
-
-	nameColor := msg.User.Color
-	if nameColor == "" {
-		nameColor = s.randomColor()
-	}
+	nameColor := s.userColor(msg.Channel, msg.User.Name, msg.User.Color)
+	taggedUsers, taggedColors := extractTags(msg.Message, func(user string) string {
+		return s.userColor(msg.Channel, user, "")
+	})
 
 	highlight, prepend, bitOffset := resolveHighlight(&msg, nameColor, s)
+	if msg.CustomRewardID != "" && prepend == "" {
+		prepend = s.rewardLabel(msg.CustomRewardID)
+	}
 
-	content := emotes.ResolveEmotes(msg.Message, msg.Emotes, s.cfg, bitOffset)
+	content := emotes.ResolveEmotes(msg.Message, msg.Emotes, s.cfg(), bitOffset)
 
 	return ChatMessage{
+		ID:           msg.ID,
 		Time:         msg.Time,
 		User:         msg.User.Name,
+		UserID:       msg.User.ID,
 		Content:      content,
 		Flare:        flare,
+		Badges:       parseBadges(msg.Tags["badges"], msg.Tags["badge-info"], msg.User.Badges),
 		NameColor:    nameColor,
 		TaggedUsers:  taggedUsers,
 		TaggedColors: taggedColors,
@@ -47,12 +51,9 @@ func (s *Service) formatUserNotice(msg twitch.UserNoticeMessage) (ChatMessage, b
 		return ChatMessage{}, false
 	}
 
-	nameColor := msg.User.Color
-	if nameColor == "" {
-		nameColor = s.randomColor()
-	}
+	nameColor := s.userColor(msg.Channel, msg.User.Name, msg.User.Color)
 
-	message := emotes.ResolveEmotes(msg.Message, msg.Emotes, s.cfg, 0)
+	message := emotes.ResolveEmotes(msg.Message, msg.Emotes, s.cfg(), 0)
 	content := msg.SystemMsg
 	var highlight string
 	if msg.Message != "" {
@@ -70,24 +71,21 @@ func (s *Service) formatUserNotice(msg twitch.UserNoticeMessage) (ChatMessage, b
 	}, true
 }
 
-// set the user flares
+// - Title (cost) - for the reward of a redemption when we know it
+func (s *Service) rewardLabel(rewardID string) string {
+	reward, ok := s.Reward(rewardID)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("- %s (%d) -", reward.Title, reward.Cost)
+}
+
+// set the user flares - roles are shown by the badges
 func resolveFlare(msg twitch.PrivateMessage) string {
 	if msg.CustomRewardID != "" {
 		return "REDEEM"
 	}
-
-	_, isMod := msg.User.Badges["moderator"]
-	_, isBroadcaster := msg.User.Badges["broadcaster"]
-	_, isVip := msg.User.Badges["vip"]
-
-	switch {
-	case isBroadcaster, isMod:
-		return "MOD"
-	case isVip:
-		return "VIP"
-	default:
-		return ""
-	}
+	return ""
 }
 
 // set the color for the highlight - also set generate the prefix for bits and first
@@ -100,8 +98,8 @@ func resolveHighlight(msg *twitch.PrivateMessage, nameColor string, s *Service)
 		prefix := fmt.Sprintf("Cheer%d", msg.Bits)
 		offset = len([]rune(prefix)) + 1 // since we cut out a part of the message we need to get the lengh of it so other operations dont fail (emotes)
 		msg.Message = strings.TrimSpace(strings.TrimPrefix(msg.Message, prefix))
-		if s.cfg.Api.Bits.Enable && s.cfg.Api.Bits.Endpoint != "" && msg.Bits >= s.cfg.Api.Bits.BitsAmount {
-			api.SendBitsNotification(s.cfg.Api.Bits.Endpoint, msg.User.Name, msg.Message, nameColor)
+		if bits := s.cfg().Api.Bits; bits.Enable && bits.Endpoint != "" && msg.Bits >= bits.BitsAmount {
+			api.SendBitsNotification(bits.Endpoint, msg.User.Name, msg.Message, nameColor)
 		}
 	case msg.FirstMessage:
 		prepend = "- First -"
@@ -111,8 +109,8 @@ func resolveHighlight(msg *twitch.PrivateMessage, nameColor string, s *Service)
 	return
 }
 
-// we generate the @users array form the message and generate a random color for each one of them
-func extractTags(message string, colorFn func() string) ([]string, map[string]string) {
+// we generate the @users array form the message and look up the color of each one of them
+func extractTags(message string, colorFn func(user string) string) ([]string, map[string]string) {
 	var taggedUsers []string
 	taggedColors := make(map[string]string)
 	seen := make(map[string]bool)
@@ -125,7 +123,7 @@ func extractTags(message string, colorFn func() string) ([]string, map[string]st
 		if user != "" && !seen[user] {
 			seen[user] = true
 			taggedUsers = append(taggedUsers, user)
-			taggedColors[user] = colorFn()
+			taggedColors[user] = colorFn(user)
 		}
 	}
 
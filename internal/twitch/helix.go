@@ -0,0 +1,51 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// the helix client takes the credentials from the service on every request
+func (t *Service) Token() (string, string) {
+	return t.AccessToken(), t.ClientID
+}
+
+// called by the helix client when twitch rejects the token it sent
+func (t *Service) Refresh(_ context.Context, rejected string) error {
+	return t.renewToken(rejected)
+}
+
+// look up the id of a user by login name
+func (t *Service) UserIDByLogin(login string) (string, error) {
+	login = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(login), "@"))
+	if login == "" {
+		return "", errors.New("missing user name")
+	}
+
+	user, err := t.helix.UserByLogin(t.ctx, login)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+// ids the moderation endpoints need - the channel we are in and us as moderator
+func (t *Service) moderationIDs() (string, string, error) {
+	if !t.Authenticated {
+		return "", "", errors.New("not logged in - use :login first")
+	}
+	if t.ChannelID == "" {
+		id, err := t.UserIDByLogin(t.CurrentChannel)
+		if err != nil {
+			return "", "", fmt.Errorf("channel ID lookup failed: %w", err)
+		}
+		t.ChannelID = id
+	}
+	if t.UserID == "" {
+		return "", "", errors.New("missing user ID - use :login again")
+	}
+
+	return t.ChannelID, t.UserID, nil
+}
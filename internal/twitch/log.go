@@ -7,7 +7,19 @@ import (
 )
 
 // todo rewirte
+// (re)open the chat log - called again when the log settings change
 func (s *Service) initLogger(cfg config.Config) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	if s.logFile != nil && cfg.Log == s.logCfg {
+		return
+	}
+	if s.logFile != nil {
+		_ = s.logFile.Close()
+		s.logFile = nil
+	}
+	s.logCfg = cfg.Log
+
 	if !cfg.Log.Enable {
 		return
 	}
@@ -30,6 +42,8 @@ func (s *Service) initLogger(cfg config.Config) {
 }
 
 func (s *Service) logRaw(raw string) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
 	if s.logFile == nil {
 		return
 	}
@@ -37,6 +51,12 @@ func (s *Service) logRaw(raw string) {
 }
 
 func (s *Service) Close() {
+	s.cancel()
+	s.stopTokenManager()
+	s.stopEventSub()
+	s.stopStreamPoller()
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
 	if s.logFile != nil {
 		_ = s.logFile.Close()
 		s.logFile = nil
@@ -0,0 +1,68 @@
+package twitch
+
+import (
+	"errors"
+	"time"
+	"twitch-tui/internal/helix"
+)
+
+// longest timeout twitch accepts
+const MaxTimeout = 14 * 24 * time.Hour
+
+// ban a user from the current channel - a duration above zero is a timeout
+func (t *Service) Ban(login string, duration time.Duration, reason string) error {
+	if duration > MaxTimeout {
+		return errors.New("timeouts can be at most 2 weeks")
+	}
+
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+	userID, err := t.UserIDByLogin(login)
+	if err != nil {
+		return err
+	}
+
+	ban := helix.Ban{UserID: userID, Reason: reason}
+	if duration > 0 {
+		ban.Duration = max(int(duration/time.Second), 1)
+	}
+	return t.helix.BanUser(t.ctx, broadcasterID, moderatorID, ban)
+}
+
+// lift a ban or timeout
+func (t *Service) Unban(login string) error {
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+	userID, err := t.UserIDByLogin(login)
+	if err != nil {
+		return err
+	}
+
+	return t.helix.UnbanUser(t.ctx, broadcasterID, moderatorID, userID)
+}
+
+// remove a single chat message
+func (t *Service) DeleteMessage(messageID string) error {
+	if messageID == "" {
+		return errors.New("missing message ID")
+	}
+	return t.deleteChatMessages(messageID)
+}
+
+// remove all messages of the current channel
+func (t *Service) ClearChat() error {
+	return t.deleteChatMessages("")
+}
+
+// without a message id helix removes every message
+func (t *Service) deleteChatMessages(messageID string) error {
+	broadcasterID, moderatorID, err := t.moderationIDs()
+	if err != nil {
+		return err
+	}
+	return t.helix.DeleteChatMessages(t.ctx, broadcasterID, moderatorID, messageID)
+}
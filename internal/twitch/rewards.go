@@ -0,0 +1,186 @@
+package twitch
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+	"twitch-tui/internal/helix"
+)
+
+const (
+	rewardReloadInterval  = time.Minute // unknown reward ids reload the list at most this often
+	maxPendingRedemptions = 200         // per reward - the queue is worked through from the oldest anyway
+)
+
+// channel point reward of the current channel
+type Reward struct {
+	ID         string
+	Title      string
+	Cost       int
+	Manageable bool // created by our client id - only those redemptions can be fulfilled or refunded
+}
+
+// redemption waiting in the queue
+type Redemption struct {
+	ID          string
+	RewardID    string
+	RewardTitle string
+	Cost        int
+	User        string
+	UserInput   string
+	RedeemedAt  time.Time
+}
+
+type rewardCache struct {
+	mu       sync.Mutex
+	rewards  map[string]Reward
+	loadedAt time.Time
+	loading  bool
+}
+
+// reward by id - an unknown id reloads the rewards in the background
+func (t *Service) Reward(id string) (Reward, bool) {
+	t.rewards.mu.Lock()
+	reward, ok := t.rewards.rewards[id]
+	reload := !ok && t.isBroadcaster() && !t.rewards.loading && time.Since(t.rewards.loadedAt) > rewardReloadInterval
+	t.rewards.mu.Unlock()
+
+	if reload {
+		go func() { _ = t.LoadRewards() }()
+	}
+	return reward, ok
+}
+
+// remember a reward we got from somewhere else - eventsub sends title and cost with every redemption
+func (t *Service) rememberReward(reward Reward) {
+	t.rewards.mu.Lock()
+	defer t.rewards.mu.Unlock()
+	if t.rewards.rewards == nil {
+		t.rewards.rewards = make(map[string]Reward)
+	}
+	if known, ok := t.rewards.rewards[reward.ID]; ok {
+		reward.Manageable = known.Manageable
+	}
+	t.rewards.rewards[reward.ID] = reward
+}
+
+// forget the rewards of the old channel
+func (t *Service) resetRewards() {
+	t.rewards.mu.Lock()
+	defer t.rewards.mu.Unlock()
+	t.rewards.rewards = nil
+	t.rewards.loadedAt = time.Time{}
+}
+
+// fetch all rewards of the channel - only the broadcaster may do that
+func (t *Service) LoadRewards() error {
+	if !t.isBroadcaster() {
+		return errors.New("only the broadcaster can manage channel point rewards")
+	}
+
+	t.rewards.mu.Lock()
+	if t.rewards.loading {
+		t.rewards.mu.Unlock()
+		return nil
+	}
+	t.rewards.loading = true
+	t.rewards.mu.Unlock()
+
+	channel := t.ChannelID
+	rewards, err := t.fetchRewards(channel, false)
+	var manageable []Reward
+	if err == nil {
+		manageable, err = t.fetchRewards(channel, true)
+	}
+
+	t.rewards.mu.Lock()
+	defer t.rewards.mu.Unlock()
+	t.rewards.loading = false
+	t.rewards.loadedAt = time.Now()
+	if err != nil {
+		return err
+	}
+
+	t.rewards.rewards = make(map[string]Reward, len(rewards))
+	for _, reward := range rewards {
+		t.rewards.rewards[reward.ID] = reward
+	}
+	for _, reward := range manageable {
+		reward.Manageable = true
+		t.rewards.rewards[reward.ID] = reward
+	}
+	return nil
+}
+
+func (t *Service) fetchRewards(broadcasterID string, onlyManageable bool) ([]Reward, error) {
+	data, err := t.helix.CustomRewards(t.ctx, broadcasterID, onlyManageable)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := make([]Reward, 0, len(data))
+	for _, reward := range data {
+		rewards = append(rewards, Reward{ID: reward.ID, Title: reward.Title, Cost: reward.Cost})
+	}
+	return rewards, nil
+}
+
+// unfulfilled redemptions of the rewards we can manage - oldest first
+func (t *Service) PendingRedemptions() ([]Redemption, error) {
+	if err := t.LoadRewards(); err != nil {
+		return nil, err
+	}
+
+	t.rewards.mu.Lock()
+	var manageable []Reward
+	for _, reward := range t.rewards.rewards {
+		if reward.Manageable {
+			manageable = append(manageable, reward)
+		}
+	}
+	t.rewards.mu.Unlock()
+
+	var redemptions []Redemption
+	for _, reward := range manageable {
+		pending, err := t.helix.Redemptions(t.ctx, t.ChannelID, reward.ID, helix.RedemptionUnfulfilled, maxPendingRedemptions)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, data := range pending {
+			redemptions = append(redemptions, Redemption{
+				ID:          data.ID,
+				RewardID:    reward.ID,
+				RewardTitle: reward.Title,
+				Cost:        reward.Cost,
+				User:        data.UserLogin,
+				UserInput:   data.UserInput,
+				RedeemedAt:  data.RedeemedAt,
+			})
+		}
+	}
+
+	slices.SortStableFunc(redemptions, func(a, b Redemption) int {
+		return a.RedeemedAt.Compare(b.RedeemedAt)
+	})
+	return redemptions, nil
+}
+
+func (t *Service) FulfillRedemption(r Redemption) error {
+	return t.updateRedemption(r, helix.RedemptionFulfilled)
+}
+
+// cancel the redemption - twitch gives the points back
+func (t *Service) RefundRedemption(r Redemption) error {
+	return t.updateRedemption(r, helix.RedemptionCanceled)
+}
+
+func (t *Service) updateRedemption(r Redemption, status string) error {
+	return t.helix.UpdateRedemptionStatus(t.ctx, t.ChannelID, r.RewardID, r.ID, status)
+}
+
+// rewards and their redemptions belong to the broadcaster alone
+func (t *Service) isBroadcaster() bool {
+	return t.ChannelID != "" && t.ChannelID == t.UserID
+}
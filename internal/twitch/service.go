@@ -1,21 +1,28 @@
 package twitch
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"strings"
+	"sync"
 	"time"
 	"twitch-tui/internal/config"
 	"twitch-tui/internal/extentions/emotes"
+	"twitch-tui/internal/helix"
 
 	"github.com/gempir/go-twitch-irc/v4"
 )
 
 type ChatMessage struct {
+	ID           string
 	Time         time.Time
 	User         string
+	UserID       string
 	Flare        string
+	Badges       []Badge
 	Content      string
 	TaggedUsers  []string
 	Highlight    string
@@ -23,35 +30,57 @@ type ChatMessage struct {
 	NameColor    string
 	TaggedColors map[string]string
 	Bits         int
+	Annotation   string // note added by a plugin - shown after the content
 }
 
+// room for bursts - the tui drains the queue once per frame
+const msgQueueSize = 1024
+
 type Service struct {
+	credMu  sync.RWMutex // client, token and refreshToken - the token manager replaces them while the tui and irc read them
 	client  *twitch.Client
 	MsgChan chan ChatMessage
 	SysChan chan string
 
+	SettingsChan chan ChatSettings // signals changed chat modes
+	chatSettings chatSettingsState
+
 	CurrentChannel string
 	User           string
 	Authenticated  bool
 	token          string
 	refreshToken   string
+	refreshMu      sync.Mutex
 	api            string
 	UserID         string
 	ChannelID      string
 	ClientID       string
 
-	cfg config.Config
-
+	store    *config.Store
+	ctx      context.Context // cancelled on Close - stops running helix requests
+	cancel   context.CancelFunc
+	helix    *helix.Client
+	eventSub eventSub
+	rewards  rewardCache
+	stream   streamPoller
+	tokens   tokenManager
+	colors   userColors
+
+	logMu   sync.Mutex
 	logFile *os.File
+	logCfg  config.Log // settings the log file was opened with
 }
 
 // init new twitch irc connection. first without an user then - when set log ourself in
-func New(cfg config.Config) *Service {
+func New(store *config.Store) *Service {
+	cfg := store.Get()
 	s := &Service{
 		client:  twitch.NewAnonymousClient(),
-		MsgChan: make(chan ChatMessage),
+		MsgChan: make(chan ChatMessage, msgQueueSize),
 		SysChan: make(chan string),
 
+		SettingsChan: make(chan ChatSettings, 1),
+
 		CurrentChannel: cfg.Twitch.Channel,
 		User:           cfg.Twitch.User,
 		token:          cfg.Twitch.Oauth,
@@ -61,72 +90,131 @@ func New(cfg config.Config) *Service {
 		ChannelID:      cfg.Twitch.ChannelID,
 		ClientID:       cfg.Twitch.ClientID,
 
-		cfg: cfg,
+		store: store,
 	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.helix = helix.New(cfg.Helix.BaseURL, s)
+	go s.watchConfig(store.Subscribe())
 
 	if s.token != "" {
 		s.login()
+		s.startTokenManager()
 	}
 
 	// when we have the twitch channel id and the emotes are enabled cache them
-	if s.ChannelID != "" {
-		if cfg.Emotes.SevenTv.Enable {
-			go func() {
-				if err := emotes.Init7tvCache(s.ChannelID); err != nil {
-					log.Printf("7tv emote cache: %v", err)
-				}
-			}()
-		}
-		if cfg.Emotes.Bttv.Enable {
-			go func() {
-				if err := emotes.InitBttvCache(s.ChannelID); err != nil {
-					log.Printf("bttv emote cache: %v", err)
-				}
-			}()
-		}
-		if cfg.Emotes.Ffz.Enable {
-			go func() {
-				if err := emotes.InitFfzCache(s.ChannelID); err != nil {
-					log.Printf("ffz emote cache: %v", err)
-				}
-			}()
-		}
-	}
+	s.cacheEmotes(config.Emotes{}, cfg.Emotes, func(err string) { log.Print(err) })
 
 	s.initLogger(cfg) // inti the message logger
 
 	return s
 }
 
-func (s *Service) UpdateConfig(cfg config.Config) {
-	s.cfg = cfg
+// the current config - changed by the tui through the store
+func (s *Service) cfg() config.Config {
+	return s.store.Get()
+}
+
+// follow config changes that need more than reading the config again
+func (s *Service) watchConfig(updates <-chan config.Config) {
+	emotes := s.cfg().Emotes
+	for {
+		select {
+		case cfg := <-updates:
+			s.helix.SetBaseURL(cfg.Helix.BaseURL)
+			s.initLogger(cfg)
+			s.cacheEmotes(emotes, cfg.Emotes, func(err string) { s.SysChan <- err })
+			emotes = cfg.Emotes
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// load the emotes of providers that are enabled now but were not before
+// disabled ones keep their cache - formatting skips them
+func (s *Service) cacheEmotes(old, cfg config.Emotes, report func(string)) {
+	if s.ChannelID == "" {
+		return
+	}
+
+	channelID := s.ChannelID
+	providers := []struct {
+		name     string
+		was, now bool
+		init     func(string) error
+	}{
+		{"7tv", old.SevenTv.Enable, cfg.SevenTv.Enable, emotes.Init7tvCache},
+		{"bttv", old.Bttv.Enable, cfg.Bttv.Enable, emotes.InitBttvCache},
+		{"ffz", old.Ffz.Enable, cfg.Ffz.Enable, emotes.InitFfzCache},
+	}
+	for _, provider := range providers {
+		if provider.was || !provider.now {
+			continue
+		}
+		go func() {
+			if err := provider.init(channelID); err != nil {
+				report(fmt.Sprintf("%s emote cache: %v", provider.name, err))
+			}
+		}()
+	}
 }
 
 func (s *Service) AccessToken() string {
+	s.credMu.RLock()
+	defer s.credMu.RUnlock()
 	return strings.TrimPrefix(s.token, "oauth:")
 }
 
 func (s *Service) RefreshToken() string {
+	s.credMu.RLock()
+	defer s.credMu.RUnlock()
 	return s.refreshToken
 }
 
+// the irc connection - nil before the first login
+func (s *Service) ircClient() *twitch.Client {
+	s.credMu.RLock()
+	defer s.credMu.RUnlock()
+	return s.client
+}
+
+// replace both tokens - the running irc connection keeps its token, the next (re)connect uses the new one
+func (s *Service) setTokens(access, refresh string) {
+	s.credMu.Lock()
+	defer s.credMu.Unlock()
+	if access != "" && !strings.HasPrefix(access, "oauth:") {
+		access = "oauth:" + access
+	}
+	s.token = access
+	s.refreshToken = refresh
+	if s.client != nil && access != "" {
+		s.client.SetIRCToken(access)
+	}
+}
+
 // gets a random color from the theme
 func (t *Service) randomColor() string {
-	palette := []string{
-		t.cfg.Theme.Lavender,
-		t.cfg.Theme.Blue,
-		t.cfg.Theme.Sapphire,
-		t.cfg.Theme.Sky,
-		t.cfg.Theme.Teal,
-		t.cfg.Theme.Green,
-		t.cfg.Theme.Yellow,
-		t.cfg.Theme.Peach,
-		t.cfg.Theme.Maroon,
-		t.cfg.Theme.Red,
-		t.cfg.Theme.Mauve,
-		t.cfg.Theme.Pink,
-		t.cfg.Theme.Flamingo,
-		t.cfg.Theme.Rosewater,
-	}
+	palette := t.palette()
 	return palette[rand.Intn(len(palette))]
 }
+
+// accent colours of the theme
+func (t *Service) palette() []string {
+	theme := t.cfg().Theme
+	return []string{
+		theme.Lavender,
+		theme.Blue,
+		theme.Sapphire,
+		theme.Sky,
+		theme.Teal,
+		theme.Green,
+		theme.Yellow,
+		theme.Peach,
+		theme.Maroon,
+		theme.Red,
+		theme.Mauve,
+		theme.Pink,
+		theme.Flamingo,
+		theme.Rosewater,
+	}
+}
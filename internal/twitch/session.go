@@ -14,18 +14,28 @@ func (t *Service) Connect() {
 
 // set up the message listenn and on connect fetch the channel id when possible
 func (t *Service) startSession() {
-	t.client.OnPrivateMessage(func(message twitch.PrivateMessage) {
+	client := t.ircClient()
+
+	client.OnPrivateMessage(func(message twitch.PrivateMessage) {
 		t.logRaw(message.Raw)
 		t.MsgChan <- t.formatMessage(message)
 	})
 
-	t.client.OnUserNoticeMessage(func(message twitch.UserNoticeMessage) {
+	client.OnUserNoticeMessage(func(message twitch.UserNoticeMessage) {
 		if msg, ok := t.formatUserNotice(message); ok {
 			t.MsgChan <- msg
 		}
 	})
 
-	t.client.OnConnect(func() {
+	client.OnUserStateMessage(func(message twitch.UserStateMessage) { // our own colour - sent on join and after every message
+		t.userColor(message.Channel, message.User.Name, message.User.Color)
+	})
+
+	client.OnRoomStateMessage(func(message twitch.RoomStateMessage) {
+		t.applyRoomState(message.State)
+	})
+
+	client.OnConnect(func() {
 		t.SysChan <- "Connected to #" + t.CurrentChannel
 
 		// if we are not logged dont even try to fetch the ids
@@ -39,11 +49,8 @@ func (t *Service) startSession() {
 			if err := t.FetchChannelID(); err != nil {
 				t.SysChan <- "Channel ID lookup failed: " + err.Error()
 			} else {
-				if err := config.UpdateChannelID(t.ChannelID); err != nil {
-					t.SysChan <- "Failed to save channel ID: " + err.Error()
-				}
-				if err := config.UpdateUserID(t.UserID); err != nil {
-					t.SysChan <- "Failed to save user ID: " + err.Error()
+				if err := t.saveIDs(); err != nil {
+					t.SysChan <- "Failed to save channel and user ID: " + err.Error()
 				}
 			}
 		}
@@ -52,23 +59,32 @@ func (t *Service) startSession() {
 		if t.UserID == "" {
 			if err := t.FetchUserID(); err != nil {
 				t.SysChan <- "User ID lookup failed: " + err.Error()
-			} else if err := config.UpdateUserID(t.UserID); err != nil {
+			} else if err := t.saveIDs(); err != nil {
 				t.SysChan <- "Failed to save user ID: " + err.Error()
 			}
 		}
+
+		go func() { _ = t.FetchShieldMode() }() // only works for moderators
+		go func() { _ = t.LoadRewards() }()     // only works for the broadcaster
+		t.startEventSub()
+		t.startStreamPoller()
 	})
 
-	t.client.OnNoticeMessage(func(message twitch.NoticeMessage) {
+	client.OnNoticeMessage(func(message twitch.NoticeMessage) {
 		if strings.Contains(message.Message, "Login authentication failed") {
 			t.SysChan <- "Auth failed. Attempting auto-refresh..."
-			t.refresh()
+			if err := t.reconnect(); err != nil {
+				t.SysChan <- "Refresh failed: " + err.Error()
+			} else {
+				t.SysChan <- "Reconnecting..."
+			}
 		}
 	})
 
-	t.client.Join(t.CurrentChannel) // join the channel
+	client.Join(t.CurrentChannel) // join the channel
 
 	go func() {
-		if err := t.client.Connect(); err != nil {
+		if err := client.Connect(); err != nil && !errors.Is(err, twitch.ErrClientDisconnected) {
 			t.SysChan <- "Connection error: " + err.Error()
 		}
 	}()
@@ -76,19 +92,33 @@ func (t *Service) startSession() {
 
 // exit current channel and join the new one - also fetch our beloved ids
 func (t *Service) SwitchChannel(newName string) error {
-	if t.client == nil {
+	client := t.ircClient()
+	if client == nil {
 		return errors.New("client not initialized")
 	}
 
-	t.client.Depart(t.CurrentChannel)
+	client.Depart(t.CurrentChannel)
 	t.CurrentChannel = newName
 	t.ChannelID = ""
-	t.client.Join(t.CurrentChannel)
+	t.updateChatSettings(func(s *ChatSettings) { *s = ChatSettings{} }) // the new channel sends its ROOMSTATE
+	t.resetRewards()
+	client.Join(t.CurrentChannel)
 
 	if err := t.FetchChannelID(); err != nil {
 		t.SysChan <- "Channel ID lookup failed: " + err.Error()
-	} else if err := config.UpdateChannelID(t.ChannelID); err != nil {
-		t.SysChan <- "Failed to save channel ID: " + err.Error()
+		t.stopEventSub()
+		t.stopStreamPoller()
+	} else {
+		if err := t.saveIDs(); err != nil {
+			t.SysChan <- "Failed to save channel ID: " + err.Error()
+		}
+		go func() { _ = t.LoadRewards() }()
+		t.startEventSub()
+		t.startStreamPoller()
+	}
+
+	if t.Authenticated {
+		_ = t.FetchShieldMode()
 	}
 
 	t.SysChan <- "Switched to channel: " + newName
@@ -97,7 +127,67 @@ func (t *Service) SwitchChannel(newName string) error {
 
 // post text input to twitch
 func (t *Service) Say(message string) {
-	t.client.Say(t.CurrentChannel, message)
+	t.ircClient().Say(t.CurrentChannel, message)
+}
+
+// answer to a message in its reply thread
+func (t *Service) Reply(parentID, message string) {
+	t.ircClient().Reply(t.CurrentChannel, parentID, message)
+}
+
+// remember the ids of the channel and us - only for the channel that is still the configured one
+func (t *Service) saveIDs() error {
+	channel, channelID, userID := t.CurrentChannel, t.ChannelID, t.UserID
+	return t.store.Update(func(cfg *config.Config) error {
+		if cfg.Twitch.Channel == channel {
+			cfg.Twitch.ChannelID = channelID
+		}
+		cfg.Twitch.UserID = userID
+		return nil
+	})
+}
+
+// take over the account of the config - used by :account
+// call it on the tui goroutine, it changes the fields the tui reads - ConnectAccount does the network part
+func (t *Service) SwitchAccount() {
+	t.stopTokenManager()
+	t.stopEventSub()
+	t.stopStreamPoller()
+	if client := t.ircClient(); client != nil {
+		_ = client.Disconnect() // only signals the connection to close
+	}
+
+	cfg := t.cfg()
+	t.CurrentChannel = cfg.Twitch.Channel
+	t.User = cfg.Twitch.User
+	t.api = cfg.Twitch.RefreshApi
+	t.UserID = cfg.Twitch.UserID
+	t.ChannelID = cfg.Twitch.ChannelID
+	t.ClientID = cfg.Twitch.ClientID
+	t.Authenticated = false
+
+	t.updateChatSettings(func(s *ChatSettings) { *s = ChatSettings{} })
+	t.resetRewards()
+	t.tokens.mu.Lock()
+	t.tokens.status = TokenStatus{}
+	t.tokens.mu.Unlock()
+
+	t.credMu.Lock()
+	t.client = twitch.NewAnonymousClient()
+	t.token = cfg.Twitch.Oauth
+	t.refreshToken = cfg.Twitch.Refresh
+	t.credMu.Unlock()
+	if cfg.Twitch.Oauth != "" {
+		t.login()
+	}
+}
+
+// connect as the account SwitchAccount took over
+func (t *Service) ConnectAccount() {
+	if t.Authenticated {
+		t.startTokenManager()
+	}
+	t.startSession()
 }
 
 // exported login used in the login command
@@ -0,0 +1,144 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	minStreamPollInterval = 15 * time.Second // helix rate limits are per token - do not burn them on this
+	streamStaleAfter      = 3                // failed polls in a row before the status is marked stale
+)
+
+// what the channel is streaming right now
+type StreamStatus struct {
+	Known     bool // false until the first poll answered
+	Live      bool
+	Title     string
+	Category  string
+	Viewers   int
+	StartedAt time.Time
+	Stale     bool // the last polls failed - this is the last status we got
+}
+
+type streamPoller struct {
+	mu        sync.Mutex
+	status    StreamStatus
+	channelID string
+	cancel    context.CancelFunc
+}
+
+// the last polled status of the current channel
+func (t *Service) StreamStatus() StreamStatus {
+	t.stream.mu.Lock()
+	defer t.stream.mu.Unlock()
+	return t.stream.status
+}
+
+// poll the current channel - does nothing when it already polls it
+func (t *Service) startStreamPoller() {
+	if !t.Authenticated || t.ChannelID == "" {
+		return
+	}
+
+	t.stream.mu.Lock()
+	defer t.stream.mu.Unlock()
+	if t.stream.cancel != nil && t.stream.channelID == t.ChannelID {
+		return
+	}
+	if t.stream.cancel != nil {
+		t.stream.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	t.stream.cancel = cancel
+	t.stream.channelID = t.ChannelID
+	t.stream.status = StreamStatus{}
+	go t.pollStream(ctx, t.ChannelID, t.CurrentChannel)
+}
+
+func (t *Service) stopStreamPoller() {
+	t.stream.mu.Lock()
+	defer t.stream.mu.Unlock()
+	if t.stream.cancel != nil {
+		t.stream.cancel()
+		t.stream.cancel = nil
+		t.stream.channelID = ""
+	}
+	t.stream.status = StreamStatus{}
+}
+
+// ask helix for the stream every interval - going live or offline is posted to the chat
+// the interval is read again for every poll so config changes apply to the next one
+func (t *Service) pollStream(ctx context.Context, channelID, channel string) {
+	failures := 0
+	for {
+		status, err := t.fetchStream(ctx, channelID)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			t.stream.mu.Lock()
+			previous := t.stream.status
+			t.stream.status = status
+			t.stream.mu.Unlock()
+
+			switch {
+			case failures >= streamStaleAfter:
+				t.SysChan <- "Stream status is up to date again"
+			case previous.Known && !previous.Live && status.Live:
+				t.SysChan <- fmt.Sprintf("%s is live: %s (%s)", channel, status.Title, status.Category)
+			case previous.Known && previous.Live && !status.Live:
+				t.SysChan <- fmt.Sprintf("%s went offline after %s", channel, FormatUptime(time.Since(previous.StartedAt)))
+			}
+			failures = 0
+		} else if failures++; failures == streamStaleAfter { // report once - the header shows it until a poll works again
+			t.stream.mu.Lock()
+			t.stream.status.Stale = true
+			t.stream.mu.Unlock()
+			t.SysChan <- fmt.Sprintf("Stream status failed %d times: %v - the header shows the last known status", failures, err)
+		}
+
+		timer := time.NewTimer(t.streamPollInterval())
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (t *Service) streamPollInterval() time.Duration {
+	return max(time.Duration(t.cfg().Stream.PollInterval)*time.Second, minStreamPollInterval)
+}
+
+// helix get streams - no stream means offline
+func (t *Service) fetchStream(ctx context.Context, channelID string) (StreamStatus, error) {
+	stream, live, err := t.helix.StreamByUserID(ctx, channelID)
+	if err != nil {
+		return StreamStatus{}, err
+	}
+
+	status := StreamStatus{Known: true, Live: live}
+	if live {
+		status.Title = stream.Title
+		status.Category = stream.GameName
+		status.Viewers = stream.ViewerCount
+		status.StartedAt = stream.StartedAt
+	}
+	return status, nil
+}
+
+// 1h02m or 5m
+func FormatUptime(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
@@ -0,0 +1,226 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	twitchValidateURL = "https://id.twitch.tv/oauth2/validate"
+
+	tokenValidateInterval = time.Hour        // twitch wants apps to validate their token at least hourly
+	tokenRefreshAhead     = 10 * time.Minute // refresh this long before the token expires
+	tokenRetryInterval    = 5 * time.Minute  // after a failed validation or refresh
+)
+
+// the token is gone or revoked - only a refresh or a new login helps
+var errTokenInvalid = errors.New("token is invalid or expired")
+
+// what the last validation told us about the token
+type TokenStatus struct {
+	LoggedIn    bool
+	Valid       bool
+	User        string
+	Scopes      []string
+	ExpiresAt   time.Time // zero when the token does not expire
+	ValidatedAt time.Time
+	RefreshedAt time.Time
+	CanRefresh  bool
+	Err         string // last validation or refresh error
+}
+
+// scopes we ask for on login that the token does not have
+func (s TokenStatus) MissingScopes() []string {
+	var missing []string
+	for _, scope := range strings.Fields(twitchScopes) {
+		if !slices.Contains(s.Scopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+type tokenManager struct {
+	mu     sync.Mutex
+	status TokenStatus
+	cancel context.CancelFunc
+}
+
+// answer of the validate endpoint
+type tokenValidation struct {
+	ClientID  string   `json:"client_id"`
+	Login     string   `json:"login"`
+	UserID    string   `json:"user_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"` // seconds - 0 when the token does not expire
+}
+
+func (t *Service) TokenStatus() TokenStatus {
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+	status := t.tokens.status
+	status.LoggedIn = t.Authenticated
+	status.CanRefresh = t.RefreshToken() != "" && t.ClientID != ""
+	return status
+}
+
+// validate the token now and every hour - refresh it before it expires
+func (t *Service) startTokenManager() {
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+	if t.tokens.cancel != nil {
+		t.tokens.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	t.tokens.cancel = cancel
+	go t.manageToken(ctx)
+}
+
+func (t *Service) stopTokenManager() {
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+	if t.tokens.cancel != nil {
+		t.tokens.cancel()
+		t.tokens.cancel = nil
+	}
+}
+
+func (t *Service) manageToken(ctx context.Context) {
+	lastErr := ""
+	for {
+		wait, err := t.checkToken(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// only report changes - the same error every few minutes is noise
+		switch {
+		case err != nil && err.Error() != lastErr:
+			t.SysChan <- "Token check failed: " + err.Error()
+			lastErr = err.Error()
+		case err == nil && lastErr != "":
+			t.SysChan <- "Token is valid again"
+			lastErr = ""
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// validate and refresh when needed - returns when to check again
+func (t *Service) checkToken(ctx context.Context) (time.Duration, error) {
+	checked := t.AccessToken()
+	validation, err := t.validateToken(ctx, checked)
+	expiring := err == nil && validation.ExpiresIn > 0 && time.Duration(validation.ExpiresIn)*time.Second <= tokenRefreshAhead
+	if errors.Is(err, errTokenInvalid) || expiring {
+		if err := t.renewToken(checked); err != nil {
+			return tokenRetryInterval, err
+		}
+		validation, err = t.validateToken(ctx, t.AccessToken())
+	}
+	if err != nil {
+		return tokenRetryInterval, err
+	}
+
+	wait := tokenValidateInterval
+	if validation.ExpiresIn > 0 {
+		untilRefresh := time.Duration(validation.ExpiresIn)*time.Second - tokenRefreshAhead
+		wait = min(wait, max(untilRefresh, time.Minute))
+	}
+	return wait, nil
+}
+
+// ask twitch about the token - the result is kept for :auth status
+func (t *Service) validateToken(ctx context.Context, accessToken string) (tokenValidation, error) {
+	var result tokenValidation
+	err := t.requestValidation(ctx, accessToken, &result)
+
+	t.tokens.mu.Lock()
+	defer t.tokens.mu.Unlock()
+	status := &t.tokens.status
+	status.ValidatedAt = time.Now()
+	status.Valid = err == nil
+	if err != nil {
+		status.Err = err.Error()
+		return result, err
+	}
+
+	status.Err = ""
+	status.User = result.Login
+	status.Scopes = result.Scopes
+	status.ExpiresAt = time.Time{}
+	if result.ExpiresIn > 0 {
+		status.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return result, nil
+}
+
+func (t *Service) requestValidation(ctx context.Context, accessToken string, result *tokenValidation) error {
+	if accessToken == "" {
+		return errors.New("access token is required to validate")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, twitchValidateURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "OAuth "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("validate request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errTokenInvalid
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to validate token: %s %s", resp.Status, readBodySnippet(resp.Body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+	if result.UserID == "" {
+		return errors.New("token validation did not return user ID")
+	}
+	return nil
+}
+
+// refresh the token the caller saw - :auth refresh
+// nothing happens when it was already replaced in the meantime
+func (t *Service) RenewToken(current string) error {
+	return t.renewToken(current)
+}
+
+// refresh unless someone else already replaced the rejected token
+// requests failing together refresh only once - the others go on with the new token
+func (t *Service) renewToken(rejected string) error {
+	t.refreshMu.Lock()
+	defer t.refreshMu.Unlock()
+	if t.AccessToken() != rejected {
+		return nil
+	}
+	if err := t.refresh(); err != nil {
+		t.tokens.mu.Lock()
+		t.tokens.status.Err = err.Error()
+		t.tokens.mu.Unlock()
+		return err
+	}
+	return nil
+}
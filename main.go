@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"twitch-tui/internal/config"
 	"twitch-tui/internal/tui"
 
@@ -9,11 +13,30 @@ import (
 )
 
 func main() {
-	cfg := config.Load()
+	profile := flag.String("profile", "", "use the credentials, channels and theme of this profile from config.toml")
+	flag.Parse()
 
-	model := tui.New(cfg)
+	cfg, err := config.Load()
+	var invalid config.ValidationError
+	if errors.As(err, &invalid) { // one problem per line - the joined message gets too long
+		fmt.Fprintln(os.Stderr, "config.toml has errors, fix them or move the file away:")
+		for _, field := range invalid {
+			fmt.Fprintln(os.Stderr, "  "+field.Error())
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.UseProfile(*profile); err != nil {
+		log.Fatal(err)
+	}
+
+	model := tui.New(config.NewStore(cfg))
 	program := tea.NewProgram(&model, tea.WithAltScreen()) // tui using alternate screen buffer - seperate screenf from comandline
-	if _, err := program.Run(); err != nil {
+	_, err = program.Run()
+	model.Close()
+	if err != nil {
 		log.Fatal(err)
 	}
 }